@@ -2,16 +2,19 @@
 package debug
 
 import (
+	"encoding/json"
 	"expvar"
 	"net/http"
 	"net/http/pprof"
+	"sync"
 
 	"github.com/arl/statsviz"
+	"github.com/zucchini/services-golang/foundation/logger"
 )
 
 // This is not definetily APP layer code because this is going to be a very heavily protocol driven
 
-func Mux() *http.ServeMux {
+func Mux(options ...func(mux *http.ServeMux)) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// -------------------------------------------------------------------------
@@ -25,5 +28,85 @@ func Mux() *http.ServeMux {
 
 	statsviz.Register(mux)
 
+	for _, option := range options {
+		option(mux)
+	}
+
 	return mux
 }
+
+// WithOpenAPI serves the OpenAPI document of the service at
+// /debug/openapi.json. The document is generated once, the first time it's
+// requested.
+func WithOpenAPI(generate func() ([]byte, error)) func(mux *http.ServeMux) {
+	return func(mux *http.ServeMux) {
+		var doc []byte
+		var err error
+		var once sync.Once
+
+		h := func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() {
+				doc, err = generate()
+			})
+
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(doc)
+		}
+
+		mux.HandleFunc("GET /debug/openapi.json", h)
+	}
+}
+
+// WithLogLevel serves the levels of the logger at /debug/loglevel. A PUT
+// changes the level and the levels of the sources, where a null level resets
+// the source, like {"level":"INFO","sources":{"sqldb":"DEBUG","web":null}}.
+// Both methods respond with the current levels.
+func WithLogLevel(log *logger.Logger) func(mux *http.ServeMux) {
+	return func(mux *http.ServeMux) {
+		respond := func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(log.Levels())
+		}
+
+		get := func(w http.ResponseWriter, r *http.Request) {
+			respond(w)
+		}
+
+		put := func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Level   *logger.Level            `json:"level"`
+				Sources map[string]*logger.Level `json:"sources"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			ctx := r.Context()
+
+			if req.Level != nil {
+				log.SetLevel(ctx, *req.Level)
+			}
+
+			for source, level := range req.Sources {
+				if level == nil {
+					log.ResetSourceLevel(ctx, source)
+					continue
+				}
+
+				log.SetSourceLevel(ctx, source, *level)
+			}
+
+			respond(w)
+		}
+
+		mux.HandleFunc("GET /debug/loglevel", get)
+		mux.HandleFunc("PUT /debug/loglevel", put)
+	}
+}
@@ -0,0 +1,369 @@
+package mid
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// CompressConfig represents when the responses are compressed.
+type CompressConfig struct {
+	// MinSize is the smallest body compressed, since compressing small
+	// bodies makes them larger. It defaults to 1024 bytes.
+	MinSize int
+
+	// ContentTypes lists the media types compressed. A "type/*" entry
+	// allows any subtype but text/event-stream, which must be listed to be
+	// compressed. When empty, JSON, XML and text are compressed.
+	ContentTypes []string
+}
+
+const eventStreamType = "text/event-stream"
+
+var defaultCompressTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"text/*",
+}
+
+// Compress compresses the responses using the gzip or deflate encoding
+// accepted by the client. The body is buffered until the minimum size is
+// reached, so small responses are written as is. A flush, like the one of a
+// stream, starts the compression right away.
+func Compress(cfg CompressConfig) web.MidHandler {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = 1024
+	}
+
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = defaultCompressTypes
+	}
+
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+
+			// Upgraded connections take over the response writer, and the
+			// responses to HEAD requests have no body to compress.
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				return handler(ctx, w, r)
+			}
+
+			cw := compressWriter{
+				ResponseWriter: w,
+				cfg:            cfg,
+				encoding:       encoding,
+				ifNoneMatch:    r.Header.Get("If-None-Match"),
+			}
+
+			err := handler(ctx, &cw, r)
+
+			if closeErr := cw.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+
+			return err
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// negotiateEncoding returns the encoding to use based on the Accept-Encoding
+// header, preferring gzip when both are accepted with the same weight.
+func negotiateEncoding(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	weights := map[string]float64{}
+	wildcard := -1.0
+
+	for part := range strings.SplitSeq(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+
+		weights[name] = q
+	}
+
+	var best string
+	var bestQ float64
+
+	for _, enc := range []string{"gzip", "deflate"} {
+		q, ok := weights[enc]
+		if !ok {
+			q = wildcard
+		}
+
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
+
+// =============================================================================
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() any { return zlib.NewWriter(io.Discard) }}
+)
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter decides if the response is compressed once the content
+// type and enough of the body are known. Until then, the status code and the
+// body are held back.
+type compressWriter struct {
+	http.ResponseWriter
+	cfg         CompressConfig
+	encoding    string
+	ifNoneMatch string
+
+	status  int
+	buf     bytes.Buffer
+	decided bool
+	enc     encoder
+}
+
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	// Like the http package, only the first status code is used.
+	if cw.status != 0 {
+		return
+	}
+
+	// Informational responses are sent right away and don't carry the body.
+	if statusCode < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	cw.status = statusCode
+
+	if !bodyAllowed(statusCode) {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	n, _ := cw.buf.Write(b)
+
+	if cw.buf.Len() >= cw.cfg.MinSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// FlushError starts writing the response, compressing it when the content
+// type allows it, and flushes it to the client.
+func (cw *compressWriter) FlushError() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+
+		if err := cw.decide(true); err != nil {
+			return err
+		}
+	}
+
+	if cw.enc != nil {
+		if err := cw.enc.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Flush implements the http.Flusher interface.
+func (cw *compressWriter) Flush() {
+	cw.FlushError()
+}
+
+// Unwrap returns the original response writer, so http.ResponseController
+// reaches its features.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close writes what is held back and finishes the compressed stream.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			return nil
+		}
+
+		if err := cw.decide(cw.buf.Len() >= cw.cfg.MinSize); err != nil {
+			return err
+		}
+	}
+
+	if cw.enc == nil {
+		return nil
+	}
+
+	err := cw.enc.Close()
+
+	switch cw.encoding {
+	case "gzip":
+		gzipWriters.Put(cw.enc)
+	case "deflate":
+		zlibWriters.Put(cw.enc)
+	}
+	cw.enc = nil
+
+	return err
+}
+
+// decide writes the status code and what is held back of the body. The
+// response is compressed when asked to and when the response allows it.
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+
+	h := cw.Header()
+
+	compressible := bodyAllowed(cw.status) && h.Get("Content-Encoding") == "" && cw.allowedType(h.Get("Content-Type"))
+
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if compressible && compress {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		encodeETag(h, cw.encoding)
+
+		switch cw.encoding {
+		case "gzip":
+			cw.enc = gzipWriters.Get().(*gzip.Writer)
+		case "deflate":
+			cw.enc = zlibWriters.Get().(*zlib.Writer)
+		}
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	// A 304 carries the ETag of the response the client has, which is the
+	// encoded one only when that response was compressed.
+	if cw.status == http.StatusNotModified && cw.hasEncodedETag(h.Get("ETag")) {
+		encodeETag(h, cw.encoding)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+
+	cw.buf.Reset()
+
+	return err
+}
+
+func (cw *compressWriter) allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return slices.ContainsFunc(cw.cfg.ContentTypes, func(allowed string) bool {
+
+		// A stream is flushed event by event, which leaves little to
+		// compress, and some proxies hold back compressed streams, so it's
+		// only compressed when its type is listed.
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			return strings.HasPrefix(mediaType, prefix+"/") && mediaType != eventStreamType
+		}
+		return mediaType == allowed
+	})
+}
+
+// hasEncodedETag reports if the If-None-Match header of the request holds
+// the encoded form of the ETag, so the client has the compressed response.
+func (cw *compressWriter) hasEncodedETag(etag string) bool {
+	encoded := web.EncodedETag(etag, cw.encoding)
+	if encoded == etag {
+		return false
+	}
+
+	for tag := range strings.SplitSeq(cw.ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == encoded {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodeETag suffixes a strong ETag with the encoding, since the compressed
+// representation has different bytes. The validator stays strong, so it can
+// still be used with If-Match.
+// https://www.rfc-editor.org/rfc/rfc9110#section-8.8.3
+func encodeETag(h http.Header, encoding string) {
+	if etag := h.Get("ETag"); etag != "" {
+		h.Set("ETag", web.EncodedETag(etag, encoding))
+	}
+}
+
+// bodyAllowed reports if a response with the status code carries a body.
+func bodyAllowed(statusCode int) bool {
+	return statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
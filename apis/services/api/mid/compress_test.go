@@ -0,0 +1,253 @@
+package mid_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"bill"}`, 100)
+	small := `{"name":"bill"}`
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		exp         string
+	}{
+		{name: "gzip", accept: "gzip", contentType: "application/json", body: large, exp: "gzip"},
+		{name: "deflate", accept: "deflate", contentType: "application/json", body: large, exp: "deflate"},
+		{name: "both", accept: "deflate, gzip", contentType: "application/json", body: large, exp: "gzip"},
+		{name: "weights", accept: "gzip;q=0.5, deflate", contentType: "application/json", body: large, exp: "deflate"},
+		{name: "wildcard", accept: "*", contentType: "application/json", body: large, exp: "gzip"},
+		{name: "excluded", accept: "gzip;q=0, *", contentType: "application/json", body: large, exp: "deflate"},
+		{name: "refused", accept: "gzip;q=0", contentType: "application/json", body: large},
+		{name: "refused-wildcard", accept: "*;q=0", contentType: "application/json", body: large},
+		{name: "identity", accept: "", contentType: "application/json", body: large},
+		{name: "min-size", accept: "gzip", contentType: "application/json", body: small},
+		{name: "content-type", accept: "gzip", contentType: "image/png", body: large},
+		{name: "text", accept: "gzip", contentType: "text/plain; charset=utf-8", body: large, exp: "gzip"},
+		{name: "event-stream", accept: "gzip", contentType: "text/event-stream", body: large},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				w.Header().Set("Content-Type", tt.contentType)
+
+				// The body is written in chunks smaller than the minimum
+				// size, so it's buffered before the decision.
+				for chunk := range slices.Chunk([]byte(tt.body), 100) {
+					w.Write(chunk)
+				}
+
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			if err := mid.Compress(mid.CompressConfig{})(h)(r.Context(), w, r); err != nil {
+				t.Fatalf("Should be able to respond: %s", err)
+			}
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.exp {
+				t.Fatalf("Should respond with the %q encoding, got %q", tt.exp, got)
+			}
+
+			if got := decompress(t, tt.exp, w.Body); got != tt.body {
+				t.Errorf("Should receive the body, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	var flushed bool
+	h := func(ctx context.Context, rw http.ResponseWriter, r *http.Request) error {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Write([]byte("data: 1\n\n"))
+
+		if err := http.NewResponseController(rw).Flush(); err != nil {
+			return err
+		}
+
+		// The event must reach the client before the handler returns, even
+		// below the minimum size.
+		flushed = w.Flushed && w.Body.Len() > 0
+		return nil
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+
+	cfg := mid.CompressConfig{ContentTypes: []string{"text/event-stream"}}
+	if err := mid.Compress(cfg)(h)(r.Context(), w, r); err != nil {
+		t.Fatalf("Should be able to respond: %s", err)
+	}
+
+	if !flushed {
+		t.Fatal("Should write the body held back when flushed.")
+	}
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Should compress the stream, got %q", got)
+	}
+
+	if got := decompress(t, "gzip", w.Body); got != "data: 1\n\n" {
+		t.Errorf("Should receive the event, got %q", got)
+	}
+}
+
+func TestCompressConditional(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	// The middleware is in the order of the services, so the ETag the
+	// client gets back is the one of the compressed representation.
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, mid.Errors(log), mid.Compress(mid.CompressConfig{}))
+
+	users := slices.Repeat([]string{"bill"}, 500)
+
+	app.HandleFunc("GET /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, users, http.StatusOK, web.WithETag("v2"))
+	})
+
+	app.HandleFunc("PUT /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := web.CheckIfMatch(ctx, "v2"); err != nil {
+			return err
+		}
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	})
+
+	serve := func(method string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/users", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, nil)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Should compress the representation, got %q", w.Header().Get("Content-Encoding"))
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag != `"v2-gzip"` {
+		t.Fatalf("Should receive a strong ETag for the compressed representation, got %s", etag)
+	}
+
+	w = serve(http.MethodGet, map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified || w.Header().Get("ETag") != etag {
+		t.Errorf("Should receive a 304 with the ETag %s, got %d %s", etag, w.Code, w.Header().Get("ETag"))
+	}
+
+	w = serve(http.MethodPut, map[string]string{"If-Match": etag})
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Should update with the ETag the client received, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(http.MethodPut, map[string]string{"If-Match": `"v1-gzip"`})
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Should reject an update based on a stale copy, got %d", w.Code)
+	}
+}
+
+func decompress(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+
+	var r io.Reader = body
+	var err error
+
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(body)
+	case "deflate":
+		r, err = zlib.NewReader(body)
+	}
+
+	if err != nil {
+		t.Fatalf("Should be able to read the %s body: %s", encoding, err)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Should be able to read the %s body: %s", encoding, err)
+	}
+
+	return string(b)
+}
+
+func TestCompressETag(t *testing.T) {
+	body := strings.Repeat(`{"name":"bill"}`, 100)
+
+	tests := []struct {
+		name        string
+		status      int
+		etag        string
+		accept      string
+		ifNoneMatch string
+		exp         string
+	}{
+		{"strong", http.StatusOK, `"v1"`, "gzip", "", `"v1-gzip"`},
+		{"deflate", http.StatusOK, `"v1"`, "deflate", "", `"v1-deflate"`},
+		{"weak", http.StatusOK, `W/"v1"`, "gzip", "", `W/"v1"`},
+		{"identity", http.StatusOK, `"v1"`, "", "", `"v1"`},
+		{"not-modified-compressed", http.StatusNotModified, `"v1"`, "gzip", `"v1-gzip"`, `"v1-gzip"`},
+		{"not-modified-uncompressed", http.StatusNotModified, `"v1"`, "gzip", `"v1"`, `"v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", tt.etag)
+				w.WriteHeader(tt.status)
+
+				if tt.status == http.StatusOK {
+					w.Write([]byte(body))
+				}
+
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+
+			if err := mid.Compress(mid.CompressConfig{})(h)(r.Context(), w, r); err != nil {
+				t.Fatalf("Should be able to respond: %s", err)
+			}
+
+			if got := w.Header().Get("ETag"); got != tt.exp {
+				t.Errorf("Should receive the ETag %s, got %s", tt.exp, got)
+			}
+		})
+	}
+}
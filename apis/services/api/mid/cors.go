@@ -0,0 +1,82 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zucchini/services-golang/app/api/mid"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// CORSConfig represents the cross-origin policy applied to the requests.
+type CORSConfig = mid.CORSConfig
+
+// CORS applies the cross-origin policy to the requests. The preflight
+// requests are answered by the routes bound with web.App.EnableCORS, so this
+// middleware must be part of the app middleware.
+func CORS(cfg CORSConfig) web.MidHandler {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			req := mid.CORSRequest{
+				Origin:        r.Header.Get("Origin"),
+				RequestMethod: r.Header.Get("Access-Control-Request-Method"),
+			}
+
+			req.Preflight = r.Method == http.MethodOptions && req.RequestMethod != ""
+
+			if v := r.Header.Get("Access-Control-Request-Headers"); v != "" {
+				for h := range strings.SplitSeq(v, ",") {
+					req.RequestHeaders = append(req.RequestHeaders, strings.TrimSpace(h))
+				}
+			}
+
+			apply := func(res mid.CORSResult) {
+				setCORSHeaders(w.Header(), req, res)
+			}
+
+			hdl := func(ctx context.Context) error {
+				return handler(ctx, w, r)
+			}
+
+			return mid.CORS(ctx, cfg, req, apply, hdl)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+func setCORSHeaders(h http.Header, req mid.CORSRequest, res mid.CORSResult) {
+	h.Set("Access-Control-Allow-Origin", res.AllowOrigin)
+
+	if res.VaryOrigin {
+		h.Add("Vary", "Origin")
+	}
+
+	if res.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if len(res.ExposeHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(res.ExposeHeaders, ", "))
+	}
+
+	if !req.Preflight {
+		return
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	h.Set("Access-Control-Allow-Methods", strings.Join(res.AllowMethods, ", "))
+
+	if len(res.AllowHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(res.AllowHeaders, ", "))
+	}
+
+	if res.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(res.MaxAge.Seconds())))
+	}
+}
@@ -0,0 +1,205 @@
+package mid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    mid.CORSConfig
+		method string
+		header map[string]string
+		status int
+		exp    map[string]string
+	}{
+		{
+			name:   "exact-origin",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://example.com"}, ExposedHeaders: []string{"X-Request-ID"}},
+			header: map[string]string{"Origin": "https://example.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":   "https://example.com",
+				"Access-Control-Expose-Headers": "X-Request-ID",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:   "wildcard-subdomain",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}},
+			header: map[string]string{"Origin": "https://api.example.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin": "https://api.example.com",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			name:   "wildcard-nested-subdomain",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}},
+			header: map[string]string{"Origin": "https://v1.api.example.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin": "https://v1.api.example.com",
+			},
+		},
+		{
+			name:   "wildcard-subdomain-apex",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}},
+			header: map[string]string{"Origin": "https://example.com"},
+			exp:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "wildcard-subdomain-suffix",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}},
+			header: map[string]string{"Origin": "https://evilexample.com"},
+			exp:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "wildcard-subdomain-scheme",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://*.example.com"}},
+			header: map[string]string{"Origin": "http://api.example.com"},
+			exp:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "rejected-origin",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://example.com"}},
+			header: map[string]string{"Origin": "https://evil.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "",
+			},
+		},
+		{
+			name: "no-origin",
+			cfg:  mid.CORSConfig{AllowedOrigins: []string{"*"}},
+			exp:  map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "any-origin",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"*"}},
+			header: map[string]string{"Origin": "https://example.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:   "any-origin-credentials",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			header: map[string]string{"Origin": "https://example.com"},
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:   "preflight-reflected-headers",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://example.com"}, MaxAge: time.Hour},
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom, Content-Type",
+			},
+			status: http.StatusNoContent,
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":  "https://example.com",
+				"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers": "X-Custom, Content-Type",
+				"Access-Control-Max-Age":       "3600",
+				"Allow":                        "GET, POST, OPTIONS",
+			},
+		},
+		{
+			name:   "preflight-any-origin-credentials",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status: http.StatusNoContent,
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:   "preflight-header-not-allowed",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://example.com"}, AllowedHeaders: []string{"Content-Type"}},
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                         "https://example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			status: http.StatusNoContent,
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Headers": "",
+			},
+		},
+		{
+			name:   "preflight-method-not-allowed",
+			cfg:    mid.CORSConfig{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET"}},
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                        "https://example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status: http.StatusNoContent,
+			exp: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, mid.CORS(tt.cfg))
+
+			h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return web.Respond(ctx, w, nil, http.StatusNoContent)
+			}
+			app.HandleFunc("GET /users", h)
+			app.HandleFunc("POST /users", h)
+			app.EnableCORS()
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			r := httptest.NewRequest(method, "/users", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			status := tt.status
+			if status == 0 {
+				status = http.StatusNoContent
+			}
+
+			if w.Code != status {
+				t.Fatalf("Should receive a %d status code, got %d", status, w.Code)
+			}
+
+			for k, v := range tt.exp {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("Should set the %s header to %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
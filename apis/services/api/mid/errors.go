@@ -2,6 +2,7 @@ package mid
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/zucchini/services-golang/app/api/errs"
@@ -37,19 +38,50 @@ func Errors(log *logger.Logger) web.MidHandler {
 	mw := func(next web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 
+			var hijacked bool
+
 			// Encapsulate the handler to prevent HTTP protocol details from propagating
 			// down through the application layers. This maintains clean separation of concerns.
 			hdlr := func(ctx context.Context) error {
-				return next(ctx, w, r)
+				err := next(ctx, w, r)
+
+				// The client of the stream went away, which ends the stream
+				// like any other.
+				if errors.Is(err, web.ErrStreamClosed) {
+					return nil
+				}
+
+				hijacked = errors.Is(err, http.ErrHijacked) || web.IsStreaming(ctx)
+
+				// The media type and payload errors are produced by the web
+				// framework while decoding and encoding, so they need to be
+				// converted into application errors to reach the client.
+				if isWebError(err) && !errs.IsError(err) {
+					if errors.Is(err, web.ErrPreconditionFailed) {
+						return errs.New(errs.FailedPrecondition, err)
+					}
+
+					return errs.New(errs.InvalidArgument, err)
+				}
+
+				return err
 			}
 
 			// Application layer middleware. No protocol details!
 			if err := mid.Errors(ctx, log, hdlr); err != nil {
+
+				// The connection was taken over, like by a websocket, or a
+				// stream already wrote the response headers, so the error is
+				// only logged.
+				if hijacked {
+					return nil
+				}
+
 				// We test this before going to production. We do not check ok.
 				errs := err.(errs.Error)
 				// Application layer code to protocol layer code
-				code := codeStatus[errs.Code.Value()]
-				if err = web.Respond(ctx, w, errs, code); err != nil {
+				code := httpStatus(errs)
+				if err = respondError(ctx, w, errs, code); err != nil {
 					return err
 				}
 
@@ -67,3 +99,55 @@ func Errors(log *logger.Logger) web.MidHandler {
 	}
 	return mw
 }
+
+// httpStatus maps an application error to the http status code. The media
+// type errors coming from the web framework and the bodies over their limit
+// take precedence over the code.
+func httpStatus(err errs.Error) int {
+	switch {
+	case errors.Is(err, web.ErrNotAcceptable):
+		return http.StatusNotAcceptable
+
+	case errors.Is(err, web.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+
+	case errors.Is(err, web.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return codeStatus[err.Code.Value()]
+}
+
+// respondError writes the error using the format the app was configured
+// with, the application error itself or a Problem Details document.
+func respondError(ctx context.Context, w http.ResponseWriter, err errs.Error, statusCode int) error {
+	if !web.UseProblemDetails(ctx) {
+		return web.Respond(ctx, w, err, statusCode)
+	}
+
+	p := web.Problem{
+		Status: statusCode,
+		Detail: err.Message,
+		Extensions: map[string]any{
+			"code": err.Code,
+		},
+	}
+
+	if len(err.Fields) > 0 {
+		p.Extensions["fields"] = err.Fields
+	}
+
+	return web.RespondProblem(ctx, w, p)
+}
+
+func isWebError(err error) bool {
+	return errors.Is(err, web.ErrNotAcceptable) ||
+		errors.Is(err, web.ErrUnsupportedMediaType) ||
+		errors.Is(err, web.ErrInvalidPayload) ||
+		errors.Is(err, web.ErrPreconditionFailed)
+}
@@ -0,0 +1,211 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestErrorsHijacked(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", nil)
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("websocket: %w: %w", http.ErrHijacked, errors.New("bad message"))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	w := httptest.NewRecorder()
+
+	if err := mid.Errors(log)(h)(r.Context(), w, r); err != nil {
+		t.Fatalf("Should handle the error: %s", err)
+	}
+
+	if w.Body.Len() != 0 {
+		t.Errorf("Should not respond on a hijacked connection, got %q", w.Body.String())
+	}
+
+	if !strings.Contains(buf.String(), "bad message") {
+		t.Errorf("Should log the error: %s", buf.String())
+	}
+}
+
+func TestErrorsStreamClosed(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", nil)
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("sending event: %w", web.ErrStreamClosed)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	w := httptest.NewRecorder()
+
+	if err := mid.Errors(log)(h)(r.Context(), w, r); err != nil {
+		t.Fatalf("Should handle the error: %s", err)
+	}
+
+	if w.Body.Len() != 0 || buf.Len() != 0 {
+		t.Errorf("Should end the stream without an error, got %q and log %q", w.Body.String(), buf.String())
+	}
+}
+
+func TestErrorsStreamStarted(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", nil)
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, mid.Errors(log))
+
+	app.HandleFunc("GET /events", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		sse, err := web.NewSSE(ctx, w, web.WithHeartbeat(0))
+		if err != nil {
+			return err
+		}
+		defer sse.Close()
+
+		if err := sse.Send(web.Event{Data: "1"}); err != nil {
+			return err
+		}
+
+		return errs.Newf(errs.Internal, "source failed")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Should keep the status code of the stream, got %d", w.Code)
+	}
+
+	if got := w.Body.String(); got != "data: 1\n\n" {
+		t.Errorf("Should not write the error into the stream, got %q", got)
+	}
+
+	if !strings.Contains(buf.String(), "source failed") {
+		t.Errorf("Should log the error: %s", buf.String())
+	}
+}
+
+func TestErrorsMediaType(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		status      int
+	}{
+		{name: "not-acceptable", accept: "text/html", contentType: "application/json", status: http.StatusNotAcceptable},
+		{name: "unsupported", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, mid.Errors(log))
+
+			app.HandleFunc("POST /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				var req struct {
+					Name string `json:"name"`
+				}
+				if err := web.Decode(r, &req); err != nil {
+					return err
+				}
+
+				return web.Respond(ctx, w, req, http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"bill"}`))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("Should receive a %d status code, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestErrorsProblemDetails(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	tests := []struct {
+		name           string
+		problemDetails bool
+		contentType    string
+		exp            map[string]any
+	}{
+		{
+			name:           "problem",
+			problemDetails: true,
+			contentType:    web.ProblemContentType,
+			exp: map[string]any{
+				"type":     "about:blank",
+				"title":    "Conflict",
+				"status":   float64(http.StatusConflict),
+				"detail":   "user exists",
+				"instance": "/users",
+				"traceId":  "req-42",
+				"code":     "already_exists",
+			},
+		},
+		{
+			name:        "application-error",
+			contentType: "application/json",
+			exp: map[string]any{
+				"code":    "already_exists",
+				"message": "user exists",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1), ProblemDetails: tt.problemDetails}, mid.Errors(log))
+
+			app.HandleFunc("POST /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return errs.Newf(errs.AlreadyExists, "user exists")
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/users", nil)
+			r.Header.Set(web.RequestIDHeader, "req-42")
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusConflict {
+				t.Errorf("Should receive a %d status code, got %d", http.StatusConflict, w.Code)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Should respond with %s, got %s", tt.contentType, got)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Should be able to decode the error: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("Should respond the error:\ngot: %v\nexp: %v", got, tt.exp)
+			}
+		})
+	}
+}
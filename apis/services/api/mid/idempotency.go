@@ -0,0 +1,204 @@
+package mid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/idempotency"
+	"github.com/zucchini/services-golang/app/api/mid"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// IdempotencyConfig represents how the idempotency keys are kept.
+type IdempotencyConfig = mid.IdempotencyConfig
+
+// maxIdempotencyKey is the longest idempotency key accepted.
+const maxIdempotencyKey = 255
+
+// defaultMaxIdempotencyBody is the largest body of a request with a key
+// read when the config doesn't set it.
+const defaultMaxIdempotencyBody = 1 << 20
+
+// Idempotency executes the unsafe requests carrying an Idempotency-Key
+// header only once, replaying the stored response to the retries. The keys
+// are scoped by user, so this middleware must run after the authentication
+// middleware of the route, and the requests with a key which aren't
+// authenticated are rejected. The middleware is disabled without a store.
+func Idempotency(cfg IdempotencyConfig) web.MidHandler {
+	m := func(handler web.Handler) web.Handler {
+		if cfg.Store == nil {
+			return handler
+		}
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || isSafeMethod(r.Method) {
+				return handler(ctx, w, r)
+			}
+
+			if len(key) > maxIdempotencyKey {
+				return errs.Newf(errs.InvalidArgument, "idempotency: key is longer than %d characters", maxIdempotencyKey)
+			}
+
+			maxBodySize := cfg.MaxBodySize
+			if maxBodySize <= 0 {
+				maxBodySize = defaultMaxIdempotencyBody
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				return errs.Newf(errs.InvalidArgument, "idempotency: reading body: %w", err)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			req := mid.IdempotencyRequest{
+				Key:         key,
+				Fingerprint: fingerprint(r, body),
+			}
+
+			// Only the headers set by the handler are stored, so the headers
+			// of the other middleware are fresh when the response is replayed.
+			// The headers are taken when the handler writes the response,
+			// before the outer middleware like the compression changes the
+			// representation, since the body stored is the one written by
+			// the handler.
+			before := w.Header().Clone()
+			rec := responseRecorder{ResponseWriter: w}
+
+			// The response is written through the outer middleware again, so
+			// they compute the representation headers of the replay.
+			replay := func(resp idempotency.Response) error {
+				for k, v := range resp.Header {
+					if isRepresentationHeader(k) {
+						continue
+					}
+					w.Header()[k] = v
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+
+				web.GetValues(ctx).StatusCode = resp.StatusCode
+
+				w.WriteHeader(resp.StatusCode)
+
+				if _, err := w.Write(resp.Body); err != nil {
+					return err
+				}
+
+				return nil
+			}
+
+			capture := func() idempotency.Response {
+				header := make(map[string][]string)
+				for k, v := range rec.written() {
+					if !slices.Equal(before[k], v) {
+						header[k] = v
+					}
+				}
+
+				return idempotency.Response{
+					StatusCode: rec.statusCode(),
+					Header:     header,
+					Body:       rec.body.Bytes(),
+				}
+			}
+
+			hdl := func(ctx context.Context) error {
+				return handler(ctx, &rec, r)
+			}
+
+			return mid.Idempotency(ctx, cfg, req, replay, capture, hdl)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// fingerprint identifies the payload of the request.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// =============================================================================
+
+// isRepresentationHeader reports if the header describes the encoding of the
+// body on the wire, which the outer middleware sets for each response.
+func isRepresentationHeader(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case "Content-Encoding", "Content-Length":
+		return true
+	}
+
+	return false
+}
+
+// responseRecorder keeps a copy of the response written by the handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if rr.status == 0 {
+		rr.status = statusCode
+		rr.header = rr.Header().Clone()
+	}
+
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+		rr.header = rr.Header().Clone()
+	}
+
+	rr.body.Write(b)
+
+	return rr.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original response writer, so http.ResponseController
+// reaches its features.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// written returns the headers as the handler wrote them.
+func (rr *responseRecorder) written() http.Header {
+	if rr.header == nil {
+		return rr.Header()
+	}
+
+	return rr.header
+}
+
+func (rr *responseRecorder) statusCode() int {
+	if rr.status == 0 {
+		return http.StatusOK
+	}
+
+	return rr.status
+}
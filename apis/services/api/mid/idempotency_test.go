@@ -0,0 +1,274 @@
+package mid_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/idempotency"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestIdempotency(t *testing.T) {
+	type request struct {
+		body    string
+		advance time.Duration
+		exp     errs.ErrCode
+		replay  bool
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+		calls    int
+	}{
+		{
+			name: "replay",
+			requests: []request{
+				{body: `{"name":"bill"}`},
+				{body: `{"name":"bill"}`, replay: true},
+			},
+			calls: 1,
+		},
+		{
+			name: "different-payload",
+			requests: []request{
+				{body: `{"name":"bill"}`},
+				{body: `{"name":"ed"}`, exp: errs.AlreadyExists},
+			},
+			calls: 1,
+		},
+		{
+			name: "ttl-expired",
+			requests: []request{
+				{body: `{"name":"bill"}`},
+				{body: `{"name":"bill"}`, advance: 25 * time.Hour},
+			},
+			calls: 2,
+		},
+		{
+			name: "ttl-not-expired",
+			requests: []request{
+				{body: `{"name":"bill"}`},
+				{body: `{"name":"bill"}`, advance: 23 * time.Hour, replay: true},
+			},
+			calls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newIdempotencyStore()
+
+			var calls int
+			h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				calls++
+				w.Header().Set("Location", "/users/1")
+				return web.Respond(ctx, w, map[string]int{"call": calls}, http.StatusCreated)
+			}
+
+			var first string
+			for i, req := range tt.requests {
+				store.advance(req.advance)
+
+				w, err := serveIdempotent(t, store, h, "key-1", req.body)
+
+				if req.exp != (errs.ErrCode{}) {
+					if got := errs.GetError(err).Code; got != req.exp {
+						t.Fatalf("Request %d should fail with %v, got %v", i, req.exp, err)
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("Request %d should succeed: %s", i, err)
+				}
+
+				if w.Code != http.StatusCreated || w.Header().Get("Location") != "/users/1" {
+					t.Errorf("Request %d should respond the handler status and headers, got %d %v", i, w.Code, w.Header())
+				}
+
+				replayed := w.Header().Get("Idempotent-Replayed") == "true"
+				if replayed != req.replay {
+					t.Errorf("Request %d should be replayed %t, got %t", i, req.replay, replayed)
+				}
+
+				if i == 0 {
+					first = w.Body.String()
+				}
+				if req.replay && w.Body.String() != first {
+					t.Errorf("Request %d should replay the body %q, got %q", i, first, w.Body.String())
+				}
+			}
+
+			if calls != tt.calls {
+				t.Errorf("Should call the handler %d times, got %d", tt.calls, calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := newIdempotencyStore()
+
+	var retryErr error
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+		// The retry arrives while the first request still holds the key.
+		_, retryErr = serveIdempotent(t, store, nil, "key-1", `{"name":"bill"}`)
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	if _, err := serveIdempotent(t, store, h, "key-1", `{"name":"bill"}`); err != nil {
+		t.Fatalf("Should succeed: %s", err)
+	}
+
+	if got := errs.GetError(retryErr).Code; got != errs.Aborted {
+		t.Errorf("Should abort the retry with %v, got %v", errs.Aborted, retryErr)
+	}
+}
+
+func TestIdempotencyReleased(t *testing.T) {
+	store := newIdempotencyStore()
+
+	var calls int
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls++
+		if calls == 1 {
+			return errors.New("failed")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	if _, err := serveIdempotent(t, store, h, "key-1", `{}`); err == nil {
+		t.Fatal("Should fail the first request")
+	}
+
+	// The key is released by the failure, so the retry is executed.
+	if _, err := serveIdempotent(t, store, h, "key-1", `{}`); err != nil {
+		t.Fatalf("Should execute the retry: %s", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Should call the handler twice, got %d", calls)
+	}
+}
+
+func TestIdempotencyUnauthenticated(t *testing.T) {
+	store := newIdempotencyStore()
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	r.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+
+	err := mid.Idempotency(mid.IdempotencyConfig{Store: store})(h)(r.Context(), w, r)
+	if got := errs.GetError(err).Code; got != errs.Unauthenticated {
+		t.Errorf("Should reject the request with %v, got %v", errs.Unauthenticated, err)
+	}
+}
+
+// serveIdempotent sends a POST with the key through the authentication and
+// idempotency middleware. Basic auth authenticates a fixed user without any
+// lookup.
+func serveIdempotent(t *testing.T, store idempotency.Store, h web.Handler, key string, body string) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+
+	if h == nil {
+		h = func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			t.Fatal("Should not call the handler")
+			return nil
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	r.Header.Set("Idempotency-Key", key)
+	r.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("bill@example.com:gophers")))
+	w := httptest.NewRecorder()
+
+	handler := mid.AuthenticateLocal(nil)(mid.Idempotency(mid.IdempotencyConfig{Store: store})(h))
+
+	return w, handler(r.Context(), w, r)
+}
+
+// =============================================================================
+
+// idempotencyStore keeps the records in memory like the Postgres store does.
+// Its clock can be advanced to expire the records.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+	skew    time.Duration
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{
+		records: make(map[string]idempotency.Record),
+	}
+}
+
+func (s *idempotencyStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skew += d
+}
+
+func (s *idempotencyStore) Lock(ctx context.Context, rec idempotency.Record, now time.Time) (idempotency.Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := rec.UserID + ":" + rec.Key
+
+	existing, exists := s.records[id]
+	if exists && existing.ExpiresAt.Before(now.Add(s.skew)) {
+		exists = false
+	}
+
+	if exists {
+		return existing, false, nil
+	}
+
+	s.records[id] = rec
+
+	return rec, true, nil
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, rec idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := rec.UserID + ":" + rec.Key
+	if s.records[id].Token == rec.Token {
+		s.records[id] = rec
+	}
+
+	return nil
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, rec idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := rec.UserID + ":" + rec.Key
+	if s.records[id].Token == rec.Token {
+		delete(s.records, id)
+	}
+
+	return nil
+}
@@ -23,7 +23,7 @@ func Logger(log *logger.Logger) web.MidHandler {
 
 			// We do not want to use protocol-specific code in the middleware in the app layer.
 			// So we pass the handler to the app layer and let it handle the request.
-			return mid.Logger(ctx, log, r.URL.Path, r.URL.RawQuery, r.Method, r.RemoteAddr, handler)
+			return mid.Logger(ctx, log, r.Pattern, r.URL.Path, r.URL.RawQuery, r.Method, r.RemoteAddr, handler)
 		}
 
 		return h
@@ -1,20 +1,58 @@
 package mid
 
 import (
+	"bufio"
 	"context"
+	"net"
 	"net/http"
 
 	"github.com/zucchini/services-golang/app/api/mid"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
-// Metrics is a middleware that reports metrics to the application.
+// Metrics is a middleware that reports metrics to the application. The
+// bytes are counted as written to the client, so the errors and the
+// compression middleware must run after this one, for the error responses
+// to be counted as they are sent.
 func Metrics() web.MidHandler {
 	return func(next web.Handler) web.Handler {
 		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			return mid.Metrics(ctx, func(ctx context.Context) error {
-				return next(ctx, w, r)
+			cw := countingWriter{ResponseWriter: w}
+
+			written := func() int64 {
+				return cw.written
+			}
+
+			return mid.Metrics(ctx, written, func(ctx context.Context) error {
+				return next(ctx, &cw, r)
 			})
 		}
 	}
 }
+
+// =============================================================================
+
+// countingWriter counts the bytes written to the client.
+type countingWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(b)
+	cw.written += int64(n)
+
+	return n, err
+}
+
+// Hijack implements the http.Hijacker interface, which the websocket
+// upgrader asserts on the response writer.
+func (cw *countingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the original response writer, so http.ResponseController
+// reaches its features.
+func (cw *countingWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
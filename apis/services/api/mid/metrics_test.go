@@ -0,0 +1,59 @@
+package mid_test
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestMetricsErrorResponse(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+
+	tests := []struct {
+		name           string
+		problemDetails bool
+	}{
+		{name: "application-error"},
+		{name: "problem", problemDetails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := web.Config{Shutdown: make(chan os.Signal, 1), ProblemDetails: tt.problemDetails}
+
+			// The middleware is in the order of the services.
+			app := web.NewApp(cfg, mid.Metrics(), mid.Errors(log))
+
+			app.HandleFunc("POST /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return errs.Newf(errs.AlreadyExists, "user exists")
+			})
+
+			bytes := expvar.Get("bytes").(*expvar.Int).Value()
+			errors := expvar.Get("errors").(*expvar.Int).Value()
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+			if w.Body.Len() == 0 {
+				t.Fatal("Should respond the error.")
+			}
+
+			if got := expvar.Get("bytes").(*expvar.Int).Value() - bytes; got != int64(w.Body.Len()) {
+				t.Errorf("Should count the %d bytes of the error response, got %d", w.Body.Len(), got)
+			}
+
+			if got := expvar.Get("errors").(*expvar.Int).Value() - errors; got != 1 {
+				t.Errorf("Should count the error once, got %d", got)
+			}
+		})
+	}
+}
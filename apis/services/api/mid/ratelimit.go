@@ -0,0 +1,152 @@
+package mid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/zucchini/services-golang/app/api/mid"
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// RateLimitKey returns the key of the bucket a request is taken from. An
+// empty key means the request can't be identified by this key.
+type RateLimitKey func(ctx context.Context, r *http.Request) string
+
+// ByIP keys the requests by the IP address of the client. The address of the
+// connection is used, so a proxy in front of the service must not hide it.
+func ByIP() RateLimitKey {
+	return func(ctx context.Context, r *http.Request) string {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		return "ip:" + host
+	}
+}
+
+// ByUserID keys the requests by the authenticated user from mid.GetUserID.
+// The app middleware runs before the authentication, so this key only
+// identifies the requests when the rate limit is route middleware placed
+// after the authentication.
+func ByUserID() RateLimitKey {
+	return func(ctx context.Context, r *http.Request) string {
+		userID, err := mid.GetUserID(ctx)
+		if err != nil {
+			return ""
+		}
+
+		return "user:" + userID.String()
+	}
+}
+
+// ByAPIKey keys the requests by the API key sent in the header. The key is
+// hashed so it isn't kept by the store. The header is trusted as is, so a
+// client could escape the limit by sending a new key on every request
+// unless the keys are checked before the rate limit.
+func ByAPIKey(header string) RateLimitKey {
+	return func(ctx context.Context, r *http.Request) string {
+		apiKey := r.Header.Get(header)
+		if apiKey == "" {
+			return ""
+		}
+
+		sum := sha256.Sum256([]byte(apiKey))
+
+		return "apikey:" + hex.EncodeToString(sum[:])
+	}
+}
+
+// ParseRateLimitKey returns the key for its configuration name, which is ip
+// or apikey. The user key needs the authentication to run first, so
+// ByUserID must be used as route middleware instead.
+func ParseRateLimitKey(name string, apiKeyHeader string) (RateLimitKey, error) {
+	switch name {
+	case "ip":
+		return ByIP(), nil
+	case "apikey":
+		return ByAPIKey(apiKeyHeader), nil
+	case "user":
+		return nil, fmt.Errorf("rate limit key %q needs the authentication to run first, use ByUserID as route middleware", name)
+	}
+
+	return nil, fmt.Errorf("unknown rate limit key %q", name)
+}
+
+// RateLimitConfig represents the rate limit applied to the requests.
+type RateLimitConfig struct {
+	// Store keeps the buckets. The requests aren't limited when it's nil.
+	Store ratelimit.Store
+
+	// Limit is the number of requests allowed per key.
+	Limit ratelimit.Limit
+
+	// Keys are tried in order and the first one identifying the request is
+	// used. The IP address of the client is used when none does. ByUserID
+	// only identifies the requests when the rate limit runs as route
+	// middleware after the authentication.
+	Keys []RateLimitKey
+
+	// Scope separates the buckets of limits sharing the same store, like the
+	// limits of different routes.
+	Scope string
+}
+
+// RateLimit limits the rate of the requests per key. It can be used as app
+// or route middleware.
+func RateLimit(cfg RateLimitConfig) web.MidHandler {
+	keys := append(slices.Clone(cfg.Keys), ByIP())
+
+	m := func(handler web.Handler) web.Handler {
+		if cfg.Store == nil || !cfg.Limit.Enabled() {
+			return handler
+		}
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			var key string
+			for _, k := range keys {
+				if key = k(ctx, r); key != "" {
+					break
+				}
+			}
+
+			if cfg.Scope != "" {
+				key = cfg.Scope + ":" + key
+			}
+
+			apply := func(res ratelimit.Result) {
+				setRateLimitHeaders(w.Header(), cfg.Limit, res)
+			}
+
+			hdl := func(ctx context.Context) error {
+				return handler(ctx, w, r)
+			}
+
+			return mid.RateLimit(ctx, cfg.Store, cfg.Limit, key, apply, hdl)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// setRateLimitHeaders announces the limit using the RateLimit header fields.
+// https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers
+func setRateLimitHeaders(h http.Header, limit ratelimit.Limit, res ratelimit.Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(res.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ratelimit.Seconds(limit.Period)))
+
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.Itoa(ratelimit.Seconds(res.RetryAfter)))
+	}
+}
@@ -0,0 +1,262 @@
+package mid_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/apis/services/api/mid"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 10, Period: time.Minute}
+
+	tests := []struct {
+		name   string
+		res    ratelimit.Result
+		err    error
+		exp    map[string]string
+		status errs.ErrCode
+	}{
+		{
+			name: "allowed",
+			res:  ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second},
+			exp: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "9",
+				"RateLimit-Reset":     "6",
+				"RateLimit-Policy":    "10;w=60",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name: "denied",
+			res:  ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 59500 * time.Millisecond, RetryAfter: 5500 * time.Millisecond},
+			exp: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"RateLimit-Policy":    "10;w=60",
+				"Retry-After":         "6",
+			},
+			status: errs.ResourceExhausted,
+		},
+		{
+			name: "store-failure",
+			err:  errors.New("store down"),
+			exp: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := stubRateLimitStore{res: tt.res, err: tt.err}
+
+			var called bool
+			h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				called = true
+				return nil
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			w := httptest.NewRecorder()
+
+			err := mid.RateLimit(mid.RateLimitConfig{Store: store, Limit: limit})(h)(r.Context(), w, r)
+
+			if tt.status != (errs.ErrCode{}) {
+				if got := errs.GetError(err).Code; got != tt.status {
+					t.Fatalf("Should fail with %v, got %v", tt.status, err)
+				}
+				if called {
+					t.Error("Should not call the handler")
+				}
+			} else if err != nil || !called {
+				t.Fatalf("Should call the handler, got %v", err)
+			}
+
+			for k, v := range tt.exp {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("Should set the %s header to %q, got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	byHeader := func(ctx context.Context, r *http.Request) string {
+		return r.Header.Get("X-Client")
+	}
+
+	handler := mid.RateLimit(mid.RateLimitConfig{Store: store, Limit: limit, Keys: []mid.RateLimitKey{byHeader}})(h)
+
+	tests := []struct {
+		name    string
+		addr    string
+		client  string
+		allowed bool
+	}{
+		{name: "ip", addr: "10.0.0.1:1234", allowed: true},
+		{name: "ip-again", addr: "10.0.0.1:4321", allowed: false},
+		{name: "other-ip", addr: "10.0.0.2:1234", allowed: true},
+		{name: "client", addr: "10.0.0.1:1234", client: "a", allowed: true},
+		{name: "client-again", addr: "10.0.0.2:1234", client: "a", allowed: false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.RemoteAddr = tt.addr
+		if tt.client != "" {
+			r.Header.Set("X-Client", tt.client)
+		}
+		w := httptest.NewRecorder()
+
+		err := handler(r.Context(), w, r)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: should allow the request %t, got %v", tt.name, tt.allowed, err)
+		}
+	}
+}
+
+func TestRateLimitByUserID(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		handler func(store ratelimit.Store) web.Handler
+		allowed bool
+	}{
+		{
+			// The user is known after the authentication, so the second
+			// request of the user is limited whatever its address.
+			name: "after-authentication",
+			handler: func(store ratelimit.Store) web.Handler {
+				rl := mid.RateLimit(mid.RateLimitConfig{Store: store, Limit: limit, Keys: []mid.RateLimitKey{mid.ByUserID()}})
+				return mid.AuthenticateLocal(nil)(rl(h))
+			},
+			allowed: false,
+		},
+		{
+			// Before the authentication the user is unknown, so the
+			// requests fall back to the address of the client.
+			name: "before-authentication",
+			handler: func(store ratelimit.Store) web.Handler {
+				rl := mid.RateLimit(mid.RateLimitConfig{Store: store, Limit: limit, Keys: []mid.RateLimitKey{mid.ByUserID()}})
+				return rl(mid.AuthenticateLocal(nil)(h))
+			},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handler(ratelimit.NewMemoryStore())
+
+			var err error
+			for _, addr := range []string{"10.0.0.1:1234", "10.0.0.2:1234"} {
+				r := httptest.NewRequest(http.MethodGet, "/users", nil)
+				r.RemoteAddr = addr
+				r.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("bill@example.com:gophers")))
+
+				err = handler(r.Context(), httptest.NewRecorder(), r)
+			}
+
+			if allowed := err == nil; allowed != tt.allowed {
+				t.Errorf("Should allow the second request %t, got %v", tt.allowed, err)
+			}
+		})
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	handler := mid.RateLimit(mid.RateLimitConfig{Store: store, Limit: limit, Keys: []mid.RateLimitKey{mid.ByAPIKey("X-API-Key")}})(h)
+
+	tests := []struct {
+		name    string
+		addr    string
+		apiKey  string
+		allowed bool
+	}{
+		{name: "key", addr: "10.0.0.1:1234", apiKey: "key-a", allowed: true},
+		{name: "key-again", addr: "10.0.0.2:1234", apiKey: "key-a", allowed: false},
+		{name: "other-key", addr: "10.0.0.1:1234", apiKey: "key-b", allowed: true},
+		{name: "no-key", addr: "10.0.0.1:1234", allowed: true},
+		{name: "no-key-again", addr: "10.0.0.1:1234", allowed: false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.RemoteAddr = tt.addr
+		if tt.apiKey != "" {
+			r.Header.Set("X-API-Key", tt.apiKey)
+		}
+
+		err := handler(r.Context(), httptest.NewRecorder(), r)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("%s: should allow the request %t, got %v", tt.name, tt.allowed, err)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set("X-API-Key", "key-a")
+	if key := mid.ByAPIKey("X-API-Key")(r.Context(), r); strings.Contains(key, "key-a") {
+		t.Errorf("Should not keep the api key in the bucket key, got %q", key)
+	}
+}
+
+func TestParseRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "ip", valid: true},
+		{name: "apikey", valid: true},
+		{name: "user"},
+		{name: "token"},
+	}
+
+	for _, tt := range tests {
+		key, err := mid.ParseRateLimitKey(tt.name, "X-API-Key")
+		if valid := err == nil && key != nil; valid != tt.valid {
+			t.Errorf("%s: should parse the key %t, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+type stubRateLimitStore struct {
+	res ratelimit.Result
+	err error
+}
+
+func (s stubRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	return s.res, s.err
+}
@@ -0,0 +1,70 @@
+package webtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Diff returns a line diff of the values encoded as indented JSON. Lines
+// only found in got are prefixed with "-" and lines only found in exp with
+// "+". It returns an empty string when the encodings are the same.
+func Diff(got any, exp any) string {
+	gotLines := jsonLines(got)
+	expLines := jsonLines(exp)
+
+	// lcs[i][j] holds the length of the longest common subsequence of
+	// gotLines[i:] and expLines[j:].
+	lcs := make([][]int, len(gotLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(expLines)+1)
+	}
+
+	for i := len(gotLines) - 1; i >= 0; i-- {
+		for j := len(expLines) - 1; j >= 0; j-- {
+			if gotLines[i] == expLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var b strings.Builder
+	var changed bool
+
+	i, j := 0, 0
+	for i < len(gotLines) || j < len(expLines) {
+		switch {
+		case i < len(gotLines) && j < len(expLines) && gotLines[i] == expLines[j]:
+			fmt.Fprintf(&b, "  %s\n", gotLines[i])
+			i++
+			j++
+
+		case j == len(expLines) || (i < len(gotLines) && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&b, "- %s\n", gotLines[i])
+			changed = true
+			i++
+
+		default:
+			fmt.Fprintf(&b, "+ %s\n", expLines[j])
+			changed = true
+			j++
+		}
+	}
+
+	if !changed {
+		return ""
+	}
+
+	return b.String()
+}
+
+func jsonLines(v any) []string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return []string{fmt.Sprintf("%#v", v)}
+	}
+
+	return strings.Split(string(data), "\n")
+}
@@ -0,0 +1,231 @@
+// Package webtest provides support for testing the routes of a service
+// end to end, running the requests in process against the http.Handler
+// built by its mux.
+package webtest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/keystore"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+const (
+	// KID is the key identifier of the key generated for the test.
+	KID = "webtest"
+
+	// Issuer is the issuer of the tokens minted for the test.
+	Issuer = "service project"
+)
+
+// Test holds the systems required to run the requests against a service.
+type Test struct {
+	Log     *logger.Logger
+	Auth    *auth.Auth
+	Handler http.Handler
+
+	t *testing.T
+}
+
+// New constructs a Test with a logger, and an auth backed by an in-memory
+// keystore holding a generated key. The build function binds the routes of
+// the service, usually by calling its mux. The logs are written to the test
+// output when the test fails.
+func New(t *testing.T, build func(log *logger.Logger, a *auth.Auth) http.Handler) *Test {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "00000000-0000-0000-0000-000000000000" })
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Logf("******************** LOGS ********************\n%s******************** LOGS ********************", buf.String())
+		}
+	})
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a private key: %s", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(pk),
+	})
+
+	ks := keystore.New()
+	if err := ks.AddPrivateKey(KID, string(privatePEM)); err != nil {
+		t.Fatalf("Should be able to add the private key: %s", err)
+	}
+
+	a := auth.New(auth.Config{
+		Log:       log,
+		KeyLookup: ks,
+		Issuer:    Issuer,
+	})
+
+	return &Test{
+		Log:     log,
+		Auth:    a,
+		Handler: build(log, a),
+		t:       t,
+	}
+}
+
+// Token mints a token for the user with the roles.
+func (wt *Test) Token(userID uuid.UUID, roles ...string) string {
+	wt.t.Helper()
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			Issuer:    Issuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+		},
+		Roles: roles,
+	}
+
+	token, err := wt.Auth.GenerateToken(KID, claims)
+	if err != nil {
+		wt.t.Fatalf("Should be able to generate a token: %s", err)
+	}
+
+	return token
+}
+
+// =============================================================================
+
+// Case represents a request to run and the response expected for it.
+type Case struct {
+	Name   string
+	Method string
+	URL    string
+	Token  string
+	Header http.Header
+
+	// Body is encoded as JSON, unless it's a string or a []byte which are
+	// sent as is.
+	Body any
+
+	StatusCode int
+
+	// GotResp is a pointer the response is decoded into, which is compared
+	// with ExpResp. The response isn't checked when it's nil.
+	GotResp any
+	ExpResp any
+
+	// CmpFunc compares the decoded response with the expected one and
+	// returns the differences. By default the values must be equal.
+	CmpFunc func(got any, exp any) string
+}
+
+// Run executes the cases as subtests.
+func (wt *Test) Run(t *testing.T, cases []Case) {
+	t.Helper()
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			wt.run(t, tc)
+		})
+	}
+}
+
+func (wt *Test) run(t *testing.T, tc Case) {
+	t.Helper()
+
+	var body io.Reader
+	switch b := tc.Body.(type) {
+	case nil:
+	case string:
+		body = bytes.NewBufferString(b)
+	case []byte:
+		body = bytes.NewBuffer(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("Should be able to encode the body: %s", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	method := tc.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	r := httptest.NewRequest(method, tc.URL, body)
+	for k, v := range tc.Header {
+		r.Header[k] = v
+	}
+
+	if body != nil && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	if tc.Token != "" {
+		r.Header.Set("Authorization", "Bearer "+tc.Token)
+	}
+
+	w := httptest.NewRecorder()
+	wt.Handler.ServeHTTP(w, r)
+
+	if w.Code != tc.StatusCode {
+		t.Fatalf("Should receive a %d status code, got %d: %s", tc.StatusCode, w.Code, w.Body.String())
+	}
+
+	if tc.GotResp == nil {
+		return
+	}
+
+	if err := json.Unmarshal(w.Body.Bytes(), tc.GotResp); err != nil {
+		t.Fatalf("Should be able to decode the response: %s: %s", err, w.Body.String())
+	}
+
+	cmp := tc.CmpFunc
+	if cmp == nil {
+		cmp = Equal
+	}
+
+	if diff := cmp(tc.GotResp, tc.ExpResp); diff != "" {
+		t.Errorf("Should receive the expected response (-got +exp):\n%s", diff)
+	}
+}
+
+// Equal returns the differences between the values when they aren't deeply
+// equal. Pointers are followed, so a pointer can be compared with a value.
+func Equal(got any, exp any) string {
+	if reflect.DeepEqual(deref(got), deref(exp)) {
+		return ""
+	}
+
+	return Diff(got, exp)
+}
+
+func deref(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return nil
+	}
+
+	return rv.Interface()
+}
@@ -2,22 +2,74 @@ package mux
 
 import (
 	"os"
+	"reflect"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/zucchini/services-golang/apis/services/api/mid"
 	"github.com/zucchini/services-golang/apis/services/auth/route/authapi"
 	"github.com/zucchini/services-golang/apis/services/auth/route/sys/checkapi"
+	"github.com/zucchini/services-golang/app/api/errs"
 	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/health"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/openapi"
 	"github.com/zucchini/services-golang/foundation/web"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Build          string
+	Log            *logger.Logger
+	DB             *sqlx.DB
+	Auth           *auth.Auth
+	Health         *health.Registry
+	Shutdown       chan os.Signal
+	Tracer         trace.Tracer
+	ProblemDetails bool
+	CORS           mid.CORSConfig
+	Compress       mid.CompressConfig
+	RateLimit      mid.RateLimitConfig
+	UserRateLimit  mid.RateLimitConfig
+}
+
 // WebAPI construct an http.Handler will all application routes bound.
-func WebAPI(build string, log *logger.Logger, db *sqlx.DB, a *auth.Auth, shutdown chan os.Signal) *web.App {
-	app := web.NewApp(shutdown, mid.Logger(log), mid.Errors(log), mid.Metrics(), mid.Panics())
+func WebAPI(cfg Config) *web.App {
+	webCfg := web.Config{
+		Shutdown:       cfg.Shutdown,
+		Tracer:         cfg.Tracer,
+		ProblemDetails: cfg.ProblemDetails,
+	}
+
+	app := web.NewApp(
+		webCfg,
+		mid.Logger(cfg.Log),
+		mid.CORS(cfg.CORS),
+		mid.Metrics(),
+		mid.Errors(cfg.Log),
+		mid.Compress(cfg.Compress),
+		mid.RateLimit(cfg.RateLimit),
+		mid.Panics(),
+	)
+
+	checkapi.Routes(cfg.Build, cfg.Log, app, cfg.Health)
+	authapi.Routes(app, cfg.Auth, mid.RateLimit(cfg.UserRateLimit))
 
-	checkapi.Routes(build, log, app, db)
-	authapi.Routes(app, a)
+	app.EnableCORS()
 
 	return app
 }
+
+// OpenAPI generates the OpenAPI document for the routes bound by WebAPI.
+func OpenAPI(build string, app *web.App) ([]byte, error) {
+	cfg := openapi.Config{
+		Info: openapi.Info{
+			Title:   "Auth API",
+			Version: build,
+		},
+		Error:          reflect.TypeFor[errs.Error](),
+		ProblemDetails: app.ProblemDetails(),
+	}
+
+	return openapi.Generate(cfg, app.Routes())
+}
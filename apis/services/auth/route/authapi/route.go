@@ -3,15 +3,20 @@ package authapi
 import (
 	"github.com/zucchini/services-golang/apis/services/api/mid"
 	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/openapi"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
-// Routes is the function that binds the authapi routes to the mux.
-func Routes(mux *web.App, a *auth.Auth) {
+// Routes is the function that binds the authapi routes to the mux. The
+// user rate limit runs after the authentication, so it's keyed by the user.
+func Routes(mux *web.App, a *auth.Auth, userRateLimit web.MidHandler) {
 
 	api := newAPI(a)
-	authenticateLocal := mid.AuthenticateLocal(a)
-	mux.HandleFunc("GET /auth/token/{kid}", api.token, authenticateLocal)
-	mux.HandleFunc("GET /auth/authenticate", api.authenticate, authenticateLocal)
-	mux.HandleFunc("POST /auth/authorize", api.authorize)
+
+	group := mux.Group("/auth")
+	group.HandleEndpoint("POST /authorize", web.JSON(api.authorize, web.NoContent()))
+
+	authenticated := group.Group("", mid.AuthenticateLocal(a), userRateLimit).Annotate(openapi.AnnotationSecurity, "bearer")
+	authenticated.HandleEndpoint("GET /token/{kid}", web.JSON(api.token))
+	authenticated.HandleEndpoint("GET /authenticate", web.JSON(api.authenticate))
 }
@@ -6,25 +6,23 @@ import (
 	"net/http"
 	"os"
 	"runtime"
-	"time"
 
-	"github.com/jmoiron/sqlx"
-	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/health"
 	"github.com/zucchini/services-golang/foundation/logger"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
 type api struct {
-	log   *logger.Logger
-	db    *sqlx.DB
-	build string
+	log    *logger.Logger
+	health *health.Registry
+	build  string
 }
 
-func newAPI(build string, log *logger.Logger, db *sqlx.DB) *api {
+func newAPI(build string, log *logger.Logger, checks *health.Registry) *api {
 	return &api{
-		build: build,
-		log:   log,
-		db:    db,
+		build:  build,
+		log:    log,
+		health: checks,
 	}
 }
 
@@ -47,14 +45,14 @@ func (api *api) liveness(ctx context.Context, w http.ResponseWriter, _ *http.Req
 	return web.Respond(ctx, w, data, http.StatusOK)
 }
 
+// readiness runs the health checks of the service. It fails as soon as the
+// service starts to shut down, so the pod stops receiving traffic.
 func (api *api) readiness(ctx context.Context, w http.ResponseWriter, _ *http.Request) error {
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
-	if err := sqldb.StatusCheck(ctx, api.db); err != nil {
-		failureStatus := "db not ready"
-		api.log.Info(ctx, "readiness failure", "status", failureStatus)
-		return web.Respond(ctx, w, map[string]string{"status": failureStatus}, http.StatusInternalServerError)
+	report := api.health.Check(ctx)
+	if !report.Ready() {
+		api.log.Info(ctx, "readiness failure", "status", report.Status)
+		return web.Respond(ctx, w, report, http.StatusServiceUnavailable)
 	}
 
-	return web.Respond(ctx, w, map[string]string{"status": "ok"}, http.StatusOK)
+	return web.Respond(ctx, w, report, http.StatusOK)
 }
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zucchini/services-golang/apis/services/api/webtest"
+	"github.com/zucchini/services-golang/apis/services/auth/mux"
+	"github.com/zucchini/services-golang/app/api/authclient"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/health"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+func TestAuthAPI(t *testing.T) {
+	wt := webtest.New(t, func(log *logger.Logger, a *auth.Auth) http.Handler {
+		cfg := mux.Config{
+			Build:    "test",
+			Log:      log,
+			Auth:     a,
+			Shutdown: make(chan os.Signal, 1),
+		}
+
+		return mux.WebAPI(cfg)
+	})
+
+	userID := uuid.MustParse("5cf37266-3473-4006-984f-9325122678b7")
+	adminToken := wt.Token(userID, "ADMIN")
+	adminClaims, err := wt.Auth.Authenticate(t.Context(), "Bearer "+adminToken)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate the token: %s", err)
+	}
+
+	wt.Run(t, []webtest.Case{
+		{
+			Name:       "readiness",
+			URL:        "/readiness",
+			StatusCode: http.StatusOK,
+			GotResp:    &health.Report{},
+			ExpResp: &health.Report{
+				Status: health.StatusOK,
+				Checks: []health.Result{},
+			},
+		},
+		{
+			Name:       "authenticate",
+			URL:        "/auth/authenticate",
+			Token:      adminToken,
+			StatusCode: http.StatusOK,
+			GotResp:    &authclient.AuthenticateResp{},
+			ExpResp: &authclient.AuthenticateResp{
+				UserID: userID,
+				Claims: adminClaims,
+			},
+		},
+		{
+			Name:       "authenticate-no-token",
+			URL:        "/auth/authenticate",
+			StatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:   "authorize",
+			Method: http.MethodPost,
+			URL:    "/auth/authorize",
+			Body: authclient.Authorize{
+				Claims: adminClaims,
+				UserID: userID,
+				Rule:   auth.RuleAdminOnly,
+			},
+			StatusCode: http.StatusNoContent,
+		},
+		{
+			Name:   "authorize-denied",
+			Method: http.MethodPost,
+			URL:    "/auth/authorize",
+			Body: authclient.Authorize{
+				Claims: adminClaims,
+				UserID: userID,
+				Rule:   auth.RuleUserOnly,
+			},
+			StatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:       "authorize-invalid",
+			Method:     http.MethodPost,
+			URL:        "/auth/authorize",
+			Body:       `{"UserID":"5cf37266-3473-4006-984f-9325122678b7"}`,
+			StatusCode: http.StatusBadRequest,
+			GotResp:    &errs.Error{},
+			ExpResp: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "Rule: is required",
+				Fields:  map[string]string{"Rule": "is required"},
+			},
+		},
+	})
+}
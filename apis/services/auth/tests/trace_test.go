@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zucchini/services-golang/apis/services/api/webtest"
+	authmux "github.com/zucchini/services-golang/apis/services/auth/mux"
+	salesmux "github.com/zucchini/services-golang/apis/services/sales/mux"
+	"github.com/zucchini/services-golang/app/api/authclient"
+	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/health"
+	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TestTraceAcrossServices follows a request from sales to auth through the
+// authclient, with tracing enabled in both services.
+func TestTraceAcrossServices(t *testing.T) {
+	// The propagator set by otel.InitTracing, so the authclient sends the
+	// traceparent of its span to auth.
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	traceIDFn := func(ctx context.Context) string {
+		return web.GetTraceID(ctx)
+	}
+
+	var authLogs bytes.Buffer
+	wt := webtest.New(t, func(_ *logger.Logger, a *auth.Auth) http.Handler {
+		cfg := authmux.Config{
+			Build:    "test",
+			Log:      logger.New(&authLogs, logger.LevelInfo, "AUTH", traceIDFn),
+			Auth:     a,
+			Shutdown: make(chan os.Signal, 1),
+			Tracer:   sdktrace.NewTracerProvider().Tracer("auth"),
+		}
+
+		return authmux.WebAPI(cfg)
+	})
+
+	authSrv := httptest.NewServer(wt.Handler)
+	defer authSrv.Close()
+
+	var salesLogs bytes.Buffer
+	salesLog := logger.New(&salesLogs, logger.LevelInfo, "SALES", traceIDFn)
+
+	cfg := salesmux.Config{
+		Build:      "test",
+		Log:        salesLog,
+		AuthClient: authclient.New(authSrv.URL, func(ctx context.Context, msg string, args ...any) { salesLog.Info(ctx, msg, args...) }),
+		Health:     health.New(),
+		Shutdown:   make(chan os.Signal, 1),
+		Tracer:     sdktrace.NewTracerProvider().Tracer("sales"),
+	}
+	sales := salesmux.WebAPI(cfg)
+
+	const requestID = "two-hop-request"
+
+	r := httptest.NewRequest(http.MethodGet, "/testauth", nil)
+	r.Header.Set("Authorization", "Bearer "+wt.Token(uuid.New(), "ADMIN"))
+	r.Header.Set(web.RequestIDHeader, requestID)
+	w := httptest.NewRecorder()
+	sales.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Should receive a %d status code, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	for name, logs := range map[string]*bytes.Buffer{"sales": &salesLogs, "auth": &authLogs} {
+		traceIDs := logTraceIDs(t, logs)
+		if len(traceIDs) == 0 {
+			t.Fatalf("Should have %s logs for the request", name)
+		}
+
+		for traceID := range traceIDs {
+			if traceID != requestID {
+				t.Errorf("Should log the %s request under %s, got %s", name, requestID, traceID)
+			}
+		}
+	}
+}
+
+// logTraceIDs returns the trace ids found in the JSON logs.
+func logTraceIDs(t *testing.T, logs *bytes.Buffer) map[string]struct{} {
+	t.Helper()
+
+	traceIDs := make(map[string]struct{})
+
+	dec := json.NewDecoder(logs)
+	for dec.More() {
+		var line struct {
+			TraceID string `json:"trace_id"`
+		}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("Should be able to decode the logs: %s", err)
+		}
+
+		traceIDs[line.TraceID] = struct{}{}
+	}
+
+	return traceIDs
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"expvar"
 	"fmt"
@@ -14,10 +15,18 @@ import (
 
 	"github.com/ardanlabs/conf/v3"
 	"github.com/zucchini/services-golang/apis/services/api/debug"
+	"github.com/zucchini/services-golang/apis/services/api/mid"
 	"github.com/zucchini/services-golang/apis/services/sales/mux"
 	"github.com/zucchini/services-golang/app/api/authclient"
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+	"github.com/zucchini/services-golang/app/api/ratelimit/ratelimitdb"
 	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/alert"
+	"github.com/zucchini/services-golang/foundation/certs"
+	"github.com/zucchini/services-golang/foundation/health"
+	"github.com/zucchini/services-golang/foundation/lifecycle"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/otel"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
@@ -26,29 +35,38 @@ var buildRef = "development"
 func main() {
 	var log *logger.Logger
 
+	// The alerts are queued until the dispatcher runs, once the
+	// configuration is known.
+	alerts := alert.New(1024)
+
 	events := logger.Events{
-		Error: func(ctx context.Context, r logger.Record) {
-			log.Info(ctx, "******* SEND ALERT *******")
-		},
+		Error: alerts.Notify,
+		Warn:  alerts.Notify,
 	}
 
 	traceIDFn := func(ctx context.Context) string {
 		return web.GetTraceID(ctx)
 	}
 
+	// The logs are written to stdout until the configuration names the
+	// outputs of the service.
+	newLog := func(sinks []logger.Sink) *logger.Logger {
+		return logger.NewWithSinks(sinks, logger.LevelInfo, "SALES", traceIDFn, events)
+	}
+
 	log = logger.NewWithEvents(os.Stdout, logger.LevelInfo, "SALES", traceIDFn, events)
 
 	// -------------------------------------------------------------------------
 
 	ctx := context.Background()
 
-	if err := run(ctx, log); err != nil {
+	if err := run(ctx, log, newLog, alerts); err != nil {
 		log.Error(ctx, "startup", "msg", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, log *logger.Logger) error {
+func run(ctx context.Context, log *logger.Logger, newLog func(sinks []logger.Sink) *logger.Logger, alerts *alert.Dispatcher) error {
 
 	// -------------------------------------------------------------------------
 	// GOMAXPROCS
@@ -60,17 +78,40 @@ func run(ctx context.Context, log *logger.Logger) error {
 
 	cfg := struct {
 		conf.Version
+		Log struct {
+			Level   string   `conf:"default:INFO"`
+			Sources []string `conf:"help:levels of packages like sqldb=DEBUG;web=WARN"`
+			Format  string   `conf:"default:json,help:one of json|logfmt|text"`
+			File    struct {
+				Path       string `conf:"help:file the logs are also written to"`
+				Format     string `conf:"default:text,help:one of json|logfmt|text"`
+				Level      string `conf:"default:INFO"`
+				MaxSize    int64  `conf:"default:104857600"`
+				MaxBackups int    `conf:"default:5"`
+			}
+		}
 		Web struct {
-			ReadTimeout        time.Duration `conf:"default:5s"`
-			WriteTimeout       time.Duration `conf:"default:10s"`
-			IdleTimeout        time.Duration `conf:"default:120s"`
-			ShutdownTimeout    time.Duration `conf:"default:20s"`
-			APIHost            string        `conf:"default:0.0.0.0:3000"`
-			DebugHost          string        `conf:"default:0.0.0.0:3010"`
-			CORSAllowedOrigins []string      `conf:"default:*,mask"`
+			ReadTimeout          time.Duration `conf:"default:5s"`
+			WriteTimeout         time.Duration `conf:"default:10s"`
+			IdleTimeout          time.Duration `conf:"default:120s"`
+			ShutdownTimeout      time.Duration `conf:"default:20s"`
+			ShutdownDrainDelay   time.Duration `conf:"default:5s"`
+			APIHost              string        `conf:"default:0.0.0.0:3000"`
+			DebugHost            string        `conf:"default:0.0.0.0:3010"`
+			CORSAllowedOrigins   []string      `conf:"default:*,mask"`
+			CORSAllowCredentials bool          `conf:"default:false"`
+			CORSExposedHeaders   []string      `conf:"default:X-Request-ID"`
+			CORSMaxAge           time.Duration `conf:"default:1h"`
+			ProblemDetails       bool          `conf:"default:false"`
+			CompressMinSize      int           `conf:"default:1024"`
+			TLSCertFile          string        `conf:"help:TLS is enabled when set"`
+			TLSKeyFile           string        `conf:"help:key of the TLS certificate"`
+			TLSMinVersion        string        `conf:"default:1.2"`
+			H2C                  bool          `conf:"default:false"`
 		}
 		Auth struct {
-			Host string `conf:"default:http://auth-service.sales-system.svc.cluster.local:6000"`
+			Host  string `conf:"default:http://auth-service.sales-system.svc.cluster.local:6000"`
+			HTTP2 bool   `conf:"default:false"`
 		}
 		DB struct {
 			User         string `conf:"default:postgres"`
@@ -81,6 +122,29 @@ func run(ctx context.Context, log *logger.Logger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		Trace struct {
+			Exporter    string  `conf:"default:none,help:one of none|stderr|file"`
+			File        string  `conf:"default:traces.json"`
+			Probability float64 `conf:"default:0.05"`
+		}
+		Alert struct {
+			WebhookURL    string        `conf:"mask"`
+			File          string        `conf:"help:file the alerts are appended to"`
+			Window        time.Duration `conf:"default:1m"`
+			Burst         int           `conf:"default:20"`
+			BatchSize     int           `conf:"default:50"`
+			FlushInterval time.Duration `conf:"default:10s"`
+		}
+		RateLimit struct {
+			Store    string        `conf:"default:memory"`
+			Requests int           `conf:"default:100"`
+			Period   time.Duration `conf:"default:1m"`
+			Burst    int           `conf:"default:0"`
+			Purge    time.Duration `conf:"default:5m,help:how often the idle buckets of the postgres store are deleted"`
+			Keys     []string      `conf:"default:ip,help:keys tried in order to limit the requests: ip or apikey"`
+			APIKey   string        `conf:"default:X-API-Key,help:header carrying the api key"`
+			PerUser  bool          `conf:"default:true,help:limit the authenticated routes per user as well"`
+		}
 	}{
 		Version: conf.Version{
 			Build: buildRef,
@@ -98,6 +162,83 @@ func run(ctx context.Context, log *logger.Logger) error {
 		return fmt.Errorf("parsing config: %w", err)
 	}
 
+	// -------------------------------------------------------------------------
+	// Log Support
+
+	// Stdout follows the levels of the logger, while the file only gets the
+	// records at or above its own level, like only the errors.
+	format, err := logger.ParseFormat(cfg.Log.Format)
+	if err != nil {
+		return fmt.Errorf("parsing log format: %w", err)
+	}
+
+	sinks := []logger.Sink{
+		{Writer: os.Stdout, Format: format, MinLevel: logger.LevelDebug},
+	}
+
+	if cfg.Log.File.Path != "" {
+		format, err := logger.ParseFormat(cfg.Log.File.Format)
+		if err != nil {
+			return fmt.Errorf("parsing log file format: %w", err)
+		}
+
+		level, err := logger.ParseLevel(cfg.Log.File.Level)
+		if err != nil {
+			return fmt.Errorf("parsing log file level: %w", err)
+		}
+
+		file, err := logger.NewRotatingFile(cfg.Log.File.Path, cfg.Log.File.MaxSize, cfg.Log.File.MaxBackups)
+		if err != nil {
+			return fmt.Errorf("opening log file: %w", err)
+		}
+		defer file.Close()
+
+		sinks = append(sinks, logger.Sink{Writer: file, Format: format, MinLevel: level})
+	}
+
+	log = newLog(sinks)
+
+	// -------------------------------------------------------------------------
+	// Log Level Support
+
+	// The levels can be changed later through the debug service.
+	level, err := logger.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return fmt.Errorf("parsing log level: %w", err)
+	}
+	log.SetLevel(ctx, level)
+
+	for _, s := range cfg.Log.Sources {
+		source, level, err := logger.ParseSourceLevel(s)
+		if err != nil {
+			return fmt.Errorf("parsing log sources: %w", err)
+		}
+		log.SetSourceLevel(ctx, source, level)
+	}
+
+	// -------------------------------------------------------------------------
+	// Alert Support
+
+	// The errors and warnings logged are notified to the sinks configured.
+	// The dispatcher runs with the other components of the service.
+	alertCfg := alert.Config{
+		Window:        cfg.Alert.Window,
+		Burst:         cfg.Alert.Burst,
+		BatchSize:     cfg.Alert.BatchSize,
+		FlushInterval: cfg.Alert.FlushInterval,
+		OnError: func(err error) {
+			log.Info(ctx, "alert", "status", "sink failed", "msg", err)
+		},
+	}
+
+	if cfg.Alert.WebhookURL != "" {
+		alertCfg.Sinks = append(alertCfg.Sinks, alert.NewWebhook(cfg.Alert.WebhookURL))
+	}
+
+	if cfg.Alert.File != "" {
+		alertCfg.Sinks = append(alertCfg.Sinks, alert.NewFile(cfg.Alert.File))
+	}
+
 	// -------------------------------------------------------------------------
 	// App Starting
 
@@ -132,20 +273,22 @@ func run(ctx context.Context, log *logger.Logger) error {
 	defer db.Close()
 
 	// -------------------------------------------------------------------------
-	// Start Debug Service
+	// Start Tracing Support
 
-	go func() {
-		// This is creating an orphaned goroutine that will run until the service shuts down.
-		// As a general rule, we don't want to start a goroutine that will be orphaned.
-		// When I say orphaned, it is because I understand that there has to be a kind of parenting
-		// relationship between the goroutine and the service.
-		// In this case, the Debug Service will be ONLY a reading service.
-		// Never change the state of the service.
-		log.Info(ctx, "startup", "debug", "debug v1 router started", "host", cfg.Web.DebugHost)
-		if err := http.ListenAndServe(cfg.Web.DebugHost, debug.Mux()); err != nil {
-			log.Error(ctx, "shutdown", "status", "debug v1 router closed", "host", cfg.Web.DebugHost, "msg", err)
-		}
-	}()
+	log.Info(ctx, "startup", "status", "initializing tracing support", "exporter", cfg.Trace.Exporter)
+
+	traceProvider, teardown, err := otel.InitTracing(log, otel.Config{
+		ServiceName: "SALES",
+		Exporter:    cfg.Trace.Exporter,
+		File:        cfg.Trace.File,
+		Probability: cfg.Trace.Probability,
+	})
+	if err != nil {
+		return fmt.Errorf("starting tracing: %w", err)
+	}
+	defer teardown(context.Background())
+
+	tracer := traceProvider.Tracer("SALES")
 
 	// -------------------------------------------------------------------------
 	// Initialize authentication support
@@ -155,9 +298,119 @@ func run(ctx context.Context, log *logger.Logger) error {
 		log.Info(ctx, "authapi", format, args)
 	}
 
-	authClient := authclient.New(cfg.Auth.Host, fnLog)
+	var authOptions []func(cln *authclient.Client)
+	if cfg.Auth.HTTP2 {
+		authOptions = append(authOptions, authclient.WithHTTP2())
+	}
+
+	authClient := authclient.New(cfg.Auth.Host, fnLog, authOptions...)
 
 	// -------------------------------------------------------------------------
+	// Initialize rate limit support
+
+	log.Info(ctx, "startup", "status", "initializing rate limit support", "store", cfg.RateLimit.Store)
+
+	// The postgres store shares the limits across the replicas of the service,
+	// while the memory store limits each replica on its own.
+	var rateLimitStore ratelimit.Store
+	var rateLimitDB *ratelimitdb.Store
+	switch cfg.RateLimit.Store {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+
+	case "postgres":
+		rateLimitDB = ratelimitdb.NewStore(log, db)
+		if err := rateLimitDB.Migrate(ctx); err != nil {
+			return fmt.Errorf("migrating rate limit store: %w", err)
+		}
+		rateLimitStore = rateLimitDB
+
+	case "none":
+
+	default:
+		return fmt.Errorf("unknown rate limit store %q", cfg.RateLimit.Store)
+	}
+
+	rateLimit := ratelimit.Limit{
+		Requests: cfg.RateLimit.Requests,
+		Period:   cfg.RateLimit.Period,
+		Burst:    cfg.RateLimit.Burst,
+	}
+
+	rateLimitKeys := make([]mid.RateLimitKey, len(cfg.RateLimit.Keys))
+	for i, name := range cfg.RateLimit.Keys {
+		if rateLimitKeys[i], err = mid.ParseRateLimitKey(name, cfg.RateLimit.APIKey); err != nil {
+			return fmt.Errorf("parsing rate limit keys: %w", err)
+		}
+	}
+
+	// The user isn't known by the app middleware, so the authenticated
+	// routes are limited per user by a route middleware of their own.
+	var userRateLimit mid.RateLimitConfig
+	if cfg.RateLimit.PerUser {
+		userRateLimit = mid.RateLimitConfig{
+			Store: rateLimitStore,
+			Limit: rateLimit,
+			Keys:  []mid.RateLimitKey{mid.ByUserID()},
+			Scope: "user",
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Initialize health checks
+
+	log.Info(ctx, "startup", "status", "initializing health checks")
+
+	checks := health.New()
+
+	checks.Register(health.Check{
+		Name:     "database",
+		Critical: true,
+		Fn: func(ctx context.Context) error {
+			return sqldb.StatusCheck(ctx, db)
+		},
+	})
+
+	// The routes that don't need authentication keep working without the
+	// auth service, so it only degrades the service.
+	checks.Register(health.Check{
+		Name:     "auth",
+		Critical: false,
+		Timeout:  2 * time.Second,
+		Fn:       authClient.Check,
+	})
+
+	// -------------------------------------------------------------------------
+	// Initialize TLS support
+
+	// TLS is optional since the cluster usually terminates it. The certificate
+	// is reloaded when the files change, so rotating it needs no restart.
+	var tlsConfig *tls.Config
+	if cfg.Web.TLSCertFile != "" {
+		log.Info(ctx, "startup", "status", "initializing TLS support", "cert", cfg.Web.TLSCertFile, "minVersion", cfg.Web.TLSMinVersion)
+
+		minVersion, err := certs.ParseVersion(cfg.Web.TLSMinVersion)
+		if err != nil {
+			return fmt.Errorf("parsing tls min version: %w", err)
+		}
+
+		reloader, err := certs.NewReloader(cfg.Web.TLSCertFile, cfg.Web.TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("loading tls certificate: %w", err)
+		}
+
+		tlsConfig = &tls.Config{
+			MinVersion:     minVersion,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	// HTTP/2 is negotiated over TLS, while h2c lets the services talk HTTP/2
+	// inside the cluster without it.
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(tlsConfig != nil)
+	protocols.SetUnencryptedHTTP2(cfg.Web.H2C)
 
 	// -------------------------------------------------------------------------
 	// Start API Service
@@ -170,50 +423,152 @@ func run(ctx context.Context, log *logger.Logger) error {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
 
+	cfgMux := mux.Config{
+		Build:          buildRef,
+		Log:            log,
+		DB:             db,
+		AuthClient:     authClient,
+		Health:         checks,
+		Shutdown:       shutdown,
+		Tracer:         tracer,
+		ProblemDetails: cfg.Web.ProblemDetails,
+		CORS: mid.CORSConfig{
+			AllowedOrigins:   cfg.Web.CORSAllowedOrigins,
+			ExposedHeaders:   cfg.Web.CORSExposedHeaders,
+			AllowCredentials: cfg.Web.CORSAllowCredentials,
+			MaxAge:           cfg.Web.CORSMaxAge,
+		},
+		Compress: mid.CompressConfig{
+			MinSize: cfg.Web.CompressMinSize,
+		},
+		RateLimit: mid.RateLimitConfig{
+			Store: rateLimitStore,
+			Limit: rateLimit,
+			Keys:  rateLimitKeys,
+		},
+		UserRateLimit: userRateLimit,
+	}
+
+	webAPI := mux.WebAPI(cfgMux)
+
 	api := http.Server{
 		Addr:         cfg.Web.APIHost,
-		Handler:      mux.WebAPI(buildRef, shutdown, db, authClient, log),
+		Handler:      webAPI,
 		ReadTimeout:  cfg.Web.ReadTimeout,
 		WriteTimeout: cfg.Web.WriteTimeout,
 		IdleTimeout:  cfg.Web.IdleTimeout,
 		ErrorLog:     logger.NewStdLogger(log, logger.LevelError),
+		TLSConfig:    tlsConfig,
+		Protocols:    &protocols,
 	}
 
-	serverErrors := make(chan error, 1)
+	// -------------------------------------------------------------------------
+	// Start Debug Service
 
-	// This goroutine is going to be the father of the rest of the goroutines when an HTTP request is made.
-	go func() {
-		log.Info(ctx, "startup", "status", "api router started", "host", api.Addr)
+	// The OpenAPI document is generated from the routes bound to the API, so
+	// the debug service starts once the API is constructed.
+	openAPI := func() ([]byte, error) {
+		return mux.OpenAPI(buildRef, webAPI)
+	}
 
-		// This error is a very low level error related to networking. I hope we do not see this error in production.
-		// I've never seen this error in production.
-		serverErrors <- api.ListenAndServe()
-	}()
+	// The Debug Service reads the state of the service, except for the log
+	// levels which can be changed with PUT /debug/loglevel.
+	dbg := http.Server{
+		Addr:     cfg.Web.DebugHost,
+		Handler:  debug.Mux(debug.WithOpenAPI(openAPI), debug.WithLogLevel(log)),
+		ErrorLog: logger.NewStdLogger(log, logger.LevelError),
+	}
 
 	// -------------------------------------------------------------------------
-	// Shutdown
+	// Start Lifecycle Support
 
-	select {
-	case err := <-serverErrors:
-		return fmt.Errorf("server error: %w", err)
+	log.Info(ctx, "startup", "status", "initializing lifecycle support")
 
-	case sig := <-shutdown:
-		log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
-		defer log.Info(ctx, "shutdown", "status", "shutdown completed", "signal", sig)
+	// The components are stopped in the order they are added, sharing the
+	// shutdown timeout.
+	mgr := lifecycle.New(log, cfg.Web.ShutdownTimeout)
+
+	// The readiness check fails from now on. The server keeps accepting
+	// traffic for a while, so Kubernetes removes the pod from the service
+	// endpoints before the server stops accepting it.
+	mgr.Add(lifecycle.Component{
+		Name: "health",
+		Stop: func(ctx context.Context) error {
+			checks.Shutdown()
+
+			select {
+			case <-time.After(cfg.Web.ShutdownDrainDelay):
+			case <-ctx.Done():
+			}
+
+			return nil
+		},
+	})
+
+	serve := api.ListenAndServe
+	if api.TLSConfig != nil {
+		serve = func() error { return api.ListenAndServeTLS("", "") }
+	}
 
-		// How long are we going to wait for all the goroutines that represent the requests
-		// to finish their execution? It has to be a reasonable amount of time.
-		// Attention!! We are executing this in Kubernetes. We need to be careful with the timeouts.
-		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
-		defer cancel()
+	apiServer := lifecycle.Server("api", &api, serve)
+	stopAPI := apiServer.Stop
 
-		// This is the graceful shutdown of the server.
-		// Do not accept any more traffic and wait for the requests to finish their execution.
-		if err := api.Shutdown(ctx); err != nil {
-			// If there is any goroutine that is blocked, we need to shut it down everything using force.
-			_ = api.Close()
-			return fmt.Errorf("could not stop server gracefully: %w", err)
+	// Websocket connections are hijacked from the server, so Shutdown does
+	// not wait for them. They are drained at the same time.
+	apiServer.Stop = func(ctx context.Context) error {
+		wsErrors := make(chan error, 1)
+		go func() {
+			wsErrors <- webAPI.ShutdownWebSockets(ctx)
+		}()
+
+		err := stopAPI(ctx)
+
+		if wsErr := <-wsErrors; wsErr != nil {
+			err = errors.Join(err, fmt.Errorf("could not drain websockets gracefully: %w", wsErr))
 		}
+
+		return err
+	}
+
+	mgr.Add(apiServer)
+	mgr.Add(lifecycle.Server("debug", &dbg, dbg.ListenAndServe))
+
+	// The memory store sweeps its buckets on its own, while the idle buckets
+	// of the postgres store are deleted in the background.
+	if rateLimitDB != nil && rateLimit.Enabled() {
+		purgeCtx, stopPurge := context.WithCancel(context.Background())
+
+		mgr.Add(lifecycle.Component{
+			Name: "ratelimit-purge",
+			Start: func(ctx context.Context) error {
+				return rateLimitDB.Purge(purgeCtx, rateLimit, cfg.RateLimit.Purge)
+			},
+			Stop: func(ctx context.Context) error {
+				stopPurge()
+				return nil
+			},
+		})
+	}
+
+	// The dispatcher stops last, so the errors logged while the other
+	// components stop are notified. It only stops on Shutdown, not when a
+	// component fails.
+	mgr.Add(lifecycle.Component{
+		Name: "alert",
+		Start: func(ctx context.Context) error {
+			return alerts.Run(context.WithoutCancel(ctx), alertCfg)
+		},
+		Stop: alerts.Shutdown,
+	})
+
+	log.Info(ctx, "startup", "status", "api router started", "host", api.Addr)
+	log.Info(ctx, "startup", "status", "debug router started", "host", dbg.Addr)
+
+	// -------------------------------------------------------------------------
+	// Shutdown
+
+	if err := mgr.Run(ctx, shutdown); err != nil {
+		return fmt.Errorf("lifecycle: %w", err)
 	}
 
 	return nil
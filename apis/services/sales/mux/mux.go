@@ -3,25 +3,72 @@ package mux
 
 import (
 	"os"
+	"reflect"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/zucchini/services-golang/apis/services/api/mid"
 	"github.com/zucchini/services-golang/apis/services/sales/route/sys/checkapi"
 	"github.com/zucchini/services-golang/app/api/authclient"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/foundation/health"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/openapi"
 	"github.com/zucchini/services-golang/foundation/web"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func WebAPI(build string, shutdown chan os.Signal, db *sqlx.DB, a *authclient.Client, log *logger.Logger) *web.App {
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Build          string
+	Log            *logger.Logger
+	DB             *sqlx.DB
+	AuthClient     *authclient.Client
+	Health         *health.Registry
+	Shutdown       chan os.Signal
+	Tracer         trace.Tracer
+	ProblemDetails bool
+	CORS           mid.CORSConfig
+	Compress       mid.CompressConfig
+	RateLimit      mid.RateLimitConfig
+	UserRateLimit  mid.RateLimitConfig
+}
+
+// WebAPI construct an http.Handler will all application routes bound.
+func WebAPI(cfg Config) *web.App {
+	webCfg := web.Config{
+		Shutdown:       cfg.Shutdown,
+		Tracer:         cfg.Tracer,
+		ProblemDetails: cfg.ProblemDetails,
+	}
+
 	mux := web.NewApp(
-		shutdown,
-		mid.Logger(log),
-		mid.Errors(log),
+		webCfg,
+		mid.Logger(cfg.Log),
+		mid.CORS(cfg.CORS),
 		mid.Metrics(),
+		mid.Errors(cfg.Log),
+		mid.Compress(cfg.Compress),
+		mid.RateLimit(cfg.RateLimit),
 		mid.Panics(), // This should be the last middleware in the chain.
 	)
 
-	checkapi.Routes(build, log, mux, db, a)
+	checkapi.Routes(cfg.Build, cfg.Log, mux, cfg.Health, cfg.AuthClient, mid.RateLimit(cfg.UserRateLimit))
+
+	mux.EnableCORS()
 
 	return mux
 }
+
+// OpenAPI generates the OpenAPI document for the routes bound by WebAPI.
+func OpenAPI(build string, app *web.App) ([]byte, error) {
+	cfg := openapi.Config{
+		Info: openapi.Info{
+			Title:   "Sales API",
+			Version: build,
+		},
+		Error:          reflect.TypeFor[errs.Error](),
+		ProblemDetails: app.ProblemDetails(),
+	}
+
+	return openapi.Generate(cfg, app.Routes())
+}
@@ -1,23 +1,28 @@
 package checkapi
 
 import (
-	"github.com/jmoiron/sqlx"
 	"github.com/zucchini/services-golang/apis/services/api/mid"
 	"github.com/zucchini/services-golang/app/api/authclient"
 	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/health"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/openapi"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
-// Routes is the function that binds the healing routes to the mux.
-func Routes(build string, log *logger.Logger, mux *web.App, db *sqlx.DB, a *authclient.Client) {
+// Routes is the function that binds the healing routes to the mux. The
+// user rate limit runs after the authentication, so it's keyed by the user.
+func Routes(build string, log *logger.Logger, mux *web.App, checks *health.Registry, a *authclient.Client, userRateLimit web.MidHandler) {
 
-	authsMw := []web.MidHandler{mid.AuthenticateOnServer(a), mid.AuthorizeOnService(a, auth.RuleAdminOnly)}
-	api := newAPI(build, log, db)
+	api := newAPI(build, log, checks)
 
 	mux.HandleFuncNoMiddleware("GET /liveness", api.liveness)
 	mux.HandleFuncNoMiddleware("GET /readiness", api.readiness)
 	mux.HandleFunc("GET /testerror", api.testErr)
 	mux.HandleFunc("GET /testpanic", api.testPanic)
-	mux.HandleFunc("GET /testauth", api.liveness, authsMw...)
+
+	admin := mux.Group("", mid.AuthenticateOnServer(a), mid.AuthorizeOnService(a, auth.RuleAdminOnly), userRateLimit).
+		Annotate(openapi.AnnotationSecurity, "bearer").
+		Annotate(openapi.AnnotationAuthRule, auth.RuleAdminOnly)
+	admin.HandleFunc("GET /testauth", api.liveness)
 }
@@ -18,6 +18,8 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/open-policy-agent/opa/v1/rego"
+	authmux "github.com/zucchini/services-golang/apis/services/auth/mux"
+	salesmux "github.com/zucchini/services-golang/apis/services/sales/mux"
 )
 
 const (
@@ -34,6 +36,7 @@ func main() {
 
 	// ------------------------------------------------------------------------------------------------
 	// Generate the Private and Public Key if the subcommand is "genkey" or the JWT if the subcommand is "genjwt"
+	// or dump the OpenAPI document of a service if the subcommand is "openapi"
 	// ------------------------------------------------------------------------------------------------
 
 	if len(os.Args) < 2 {
@@ -59,6 +62,19 @@ func main() {
 		if err := GenJWT(role); err != nil {
 			log.Fatalln(err)
 		}
+	case "openapi":
+		if len(os.Args) < 3 {
+			log.Fatalln("missing service, use auth or sales")
+		}
+
+		file := ""
+		if len(os.Args) == 4 {
+			file = os.Args[3]
+		}
+
+		if err := GenOpenAPI(os.Args[2], file); err != nil {
+			log.Fatalln(err)
+		}
 	default:
 		log.Fatalln("invalid subcommand")
 	}
@@ -151,6 +167,40 @@ func GenJWT(role string) error {
 	return nil
 }
 
+// GenOpenAPI writes the OpenAPI document of the service to the file, or to
+// stdout when no file is provided. The routes are bound without any of the
+// service dependencies since they are only described, never executed.
+func GenOpenAPI(service string, file string) error {
+	shutdown := make(chan os.Signal, 1)
+
+	var doc []byte
+	var err error
+
+	switch service {
+	case "auth":
+		doc, err = authmux.OpenAPI("admin", authmux.WebAPI(authmux.Config{Build: "admin", Shutdown: shutdown}))
+	case "sales":
+		doc, err = salesmux.OpenAPI("admin", salesmux.WebAPI(salesmux.Config{Build: "admin", Shutdown: shutdown}))
+	default:
+		return fmt.Errorf("invalid service %q, use auth or sales", service)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to generate document: %w", err)
+	}
+
+	if file == "" {
+		fmt.Println(string(doc))
+		return nil
+	}
+
+	if err := os.WriteFile(file, doc, 0644); err != nil {
+		return fmt.Errorf("unable to write document: %w", err)
+	}
+
+	return nil
+}
+
 func GenKey() error {
 
 	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
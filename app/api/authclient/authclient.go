@@ -6,9 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"time"
+
+	"github.com/zucchini/services-golang/foundation/otel"
+	"github.com/zucchini/services-golang/foundation/web"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 // This provides a default client configuration, but it is recommended
@@ -60,6 +65,36 @@ func WithClient(http *http.Client) func(cln *Client) {
 	}
 }
 
+// WithHTTP2 makes the client talk HTTP/2 to the auth service. When the url
+// isn't https, h2c is used with prior knowledge, so the auth service must
+// have h2c enabled. It replaces the client set with WithClient.
+func WithHTTP2() func(cln *Client) {
+	return func(cln *Client) {
+		tr := defaultClient.Transport.(*http.Transport).Clone()
+
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		tr.Protocols = &protocols
+
+		cln.http = &http.Client{
+			Transport: tr,
+		}
+	}
+}
+
+// Check reports if the auth service is reachable by calling its liveness
+// endpoint.
+func (cln *Client) Check(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/liveness", cln.url)
+
+	if err := cln.rawRequest(ctx, http.MethodGet, endpoint, nil, nil, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (cln *Client) Authenticate(ctx context.Context, authorization string) (AuthenticateResp, error) {
 	endpoint := fmt.Sprintf("%s/auth/authenticate", cln.url)
 
@@ -91,6 +126,9 @@ func (cln *Client) Authorize(ctx context.Context, auth Authorize) error {
 }
 
 func (cln *Client) rawRequest(ctx context.Context, method string, url string, headers map[string]string, r io.Reader, v any) error {
+	ctx, span := otel.AddClientSpan(ctx, "app.api.authclient.rawrequest", semconv.HTTPRequestMethodKey.String(method), semconv.URLFull(url))
+	defer span.End()
+
 	cln.log(ctx, "authClient rawRequest: started:", "method", method, "url", url)
 	defer cln.log(ctx, "authClient rawRequest: completed:", "method", method, url)
 
@@ -102,8 +140,12 @@ func (cln *Client) rawRequest(ctx context.Context, method string, url string, he
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Cache-Control", "no-cache")
+	otel.AddTraceToRequest(ctx, req)
+	web.SetTraceHeaders(ctx, req.Header)
 	for k, v := range headers {
-		cln.log(ctx, "rawRequest: header:", "key", k, "value", v)
+		// The values are credentials like the authorization header, so only
+		// the keys are logged.
+		cln.log(ctx, "rawRequest: header:", "key", k)
 		req.Header.Set(k, v)
 	}
 
@@ -114,6 +156,7 @@ func (cln *Client) rawRequest(ctx context.Context, method string, url string, he
 	defer resp.Body.Close()
 
 	cln.log(ctx, "rawRequest: client do:", "method", method, "url", url, "status", resp.StatusCode)
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
 
 	if resp.StatusCode == http.StatusNoContent {
 		return nil
@@ -124,20 +167,49 @@ func (cln *Client) rawRequest(ctx context.Context, method string, url string, he
 		return fmt.Errorf("read response body: %w", err)
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		if err := json.Unmarshal(data, v); err != nil {
-			return fmt.Errorf("failed: response: %s, decodeing error: %w", string(data), err)
-		}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return decodeError(resp, data)
+	}
 
+	if v == nil {
 		return nil
-	case http.StatusUnauthorized:
-		var e Error
-		if err := json.Unmarshal(data, &e); err != nil {
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed: response: %s, decodeing error: %w", string(data), err)
+	}
+
+	return nil
+}
+
+// decodeError decodes the body of an error response, which can be an
+// application error or a Problem Details document.
+func decodeError(resp *http.Response, data []byte) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if mediaType == web.ProblemContentType {
+		var p problem
+		if err := json.Unmarshal(data, &p); err != nil {
 			return fmt.Errorf("failed: response: %s, decodeing error: %w", string(data), err)
 		}
-		return e
+
+		msg := p.Detail
+		if msg == "" {
+			msg = p.Title
+		}
+
+		return Error{
+			StatusCode: resp.StatusCode,
+			Code:       p.Code,
+			Message:    msg,
+			Fields:     p.Fields,
+		}
 	}
 
-	return nil
+	e := Error{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(data, &e); err != nil || e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return e
 }
@@ -0,0 +1,94 @@
+package authclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/zucchini/services-golang/app/api/authclient"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		exp         authclient.Error
+	}{
+		{
+			name:        "problem",
+			status:      http.StatusBadRequest,
+			contentType: web.ProblemContentType,
+			body:        `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Rule: is required","code":"invalid_argument","fields":{"Rule":"is required"}}`,
+			exp: authclient.Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       "invalid_argument",
+				Message:    "Rule: is required",
+				Fields:     map[string]string{"Rule": "is required"},
+			},
+		},
+		{
+			name:        "problem-charset",
+			status:      http.StatusUnauthorized,
+			contentType: web.ProblemContentType + "; charset=utf-8",
+			body:        `{"type":"about:blank","title":"Unauthorized","status":401,"code":"unauthenticated"}`,
+			exp: authclient.Error{
+				StatusCode: http.StatusUnauthorized,
+				Code:       "unauthenticated",
+				Message:    "Unauthorized",
+			},
+		},
+		{
+			name:        "legacy",
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+			body:        `{"code":"invalid_argument","message":"Rule: is required","fields":{"Rule":"is required"}}`,
+			exp: authclient.Error{
+				StatusCode: http.StatusBadRequest,
+				Code:       "invalid_argument",
+				Message:    "Rule: is required",
+				Fields:     map[string]string{"Rule": "is required"},
+			},
+		},
+		{
+			name:        "unknown-body",
+			status:      http.StatusBadGateway,
+			contentType: "text/html",
+			body:        `<html>bad gateway</html>`,
+			exp: authclient.Error{
+				StatusCode: http.StatusBadGateway,
+				Message:    http.StatusText(http.StatusBadGateway),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			log := func(ctx context.Context, msg string, args ...any) {}
+			cln := authclient.New(srv.URL, log, authclient.WithClient(srv.Client()))
+
+			err := cln.Authorize(context.Background(), authclient.Authorize{})
+
+			var got authclient.Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Should return an authclient.Error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("Should decode the error:\ngot: %+v\nexp: %+v", got, tt.exp)
+			}
+		})
+	}
+}
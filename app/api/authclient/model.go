@@ -5,8 +5,24 @@ import (
 	"github.com/zucchini/services-golang/business/api/auth"
 )
 
+// Error represents an error returned by the auth service. The service can
+// respond errors as an application error or as a Problem Details document,
+// both are decoded into this type.
 type Error struct {
-	Message string `json:"message"`
+	StatusCode int               `json:"-"`
+	Code       string            `json:"code,omitempty"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+// problem represents the members of a Problem Details document used by the
+// auth service.
+type problem struct {
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail"`
+	Code   string            `json:"code"`
+	Fields map[string]string `json:"fields"`
 }
 
 func (e Error) Error() string {
@@ -16,8 +32,8 @@ func (e Error) Error() string {
 // Authorize defines the information required to perform an authorization
 type Authorize struct {
 	Claims auth.Claims
-	UserID uuid.UUID
-	Rule   string
+	UserID uuid.UUID `validate:"required"`
+	Rule   string    `validate:"required"`
 }
 
 // AuthenticateResp defines the information that will be received on authenticate
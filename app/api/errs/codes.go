@@ -135,8 +135,9 @@ func (ec *ErrCode) UnmarshalText(data []byte) error {
 	return nil
 }
 
-// MarshalText implement the marshal interface for JSON conversions.
-func (ec *ErrCode) MarshalText() ([]byte, error) {
+// MarshalText implement the marshal interface for JSON conversions. It uses
+// a value receiver so the code is marshaled when Error is used as a value.
+func (ec ErrCode) MarshalText() ([]byte, error) {
 	return []byte(ec.String()), nil
 }
 
@@ -6,21 +6,41 @@ import (
 )
 
 type Error struct {
-	Code    ErrCode `json:"code"`
-	Message string  `json:"message"`
+	Code    ErrCode           `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+
+	// err keeps the original error so the protocol layers can inspect the
+	// error chain. It's never sent to the client.
+	err error
 }
 
 func New(code ErrCode, err error) Error {
 	return Error{
 		Code:    code,
 		Message: err.Error(),
+		err:     err,
 	}
 }
 
 func Newf(code ErrCode, format string, a ...any) Error {
+	err := fmt.Errorf(format, a...)
+
 	return Error{
 		Code:    code,
-		Message: fmt.Sprintf(format, a...),
+		Message: err.Error(),
+		err:     err,
+	}
+}
+
+// NewFieldsError constructs an InvalidArgument error that reports the
+// failure of every field of the request.
+func NewFieldsError(err error, fields map[string]string) Error {
+	return Error{
+		Code:    InvalidArgument,
+		Message: err.Error(),
+		Fields:  fields,
+		err:     err,
 	}
 }
 
@@ -29,6 +49,11 @@ func (e Error) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the original error, if any.
+func (e Error) Unwrap() error {
+	return e.err
+}
+
 // IsError checks if the error is an Error.
 func IsError(err error) bool {
 	var e Error
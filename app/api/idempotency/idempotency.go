@@ -0,0 +1,62 @@
+// Package idempotency provides support for replaying the response of a
+// request retried with the same idempotency key, so unsafe requests are
+// executed only once.
+//
+// No service mounts the middleware yet, since none has unsafe routes. A
+// service adding them migrates the Postgres store at startup, purges its
+// expired keys with the lifecycle manager, and mounts the middleware on the
+// route group of the unsafe routes, after the authentication middleware
+// since the keys are scoped by user:
+//
+//	store := idempotencydb.NewStore(log, db)
+//	if err := store.Migrate(ctx); err != nil {
+//		return fmt.Errorf("migrating idempotency store: %w", err)
+//	}
+//
+//	purgeCtx, stopPurge := context.WithCancel(context.Background())
+//	mgr.Add(lifecycle.Component{
+//		Name:  "idempotency-purge",
+//		Start: func(ctx context.Context) error { return store.Purge(purgeCtx, time.Hour) },
+//		Stop:  func(ctx context.Context) error { stopPurge(); return nil },
+//	})
+//
+//	users := app.Group("/v1/users", mid.AuthenticateOnServer(a), mid.Idempotency(mid.IdempotencyConfig{Store: store}))
+//	users.HandleFunc("POST /", api.create)
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Response represents a completed response kept for replay.
+type Response struct {
+	StatusCode int
+	Header     map[string][]string
+	Body       []byte
+}
+
+// Record represents the claim of a request over an idempotency key. The
+// response is nil while the request is in progress.
+type Record struct {
+	UserID      string
+	Key         string
+	Fingerprint string
+	Token       string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Store defines the behavior required to keep the records.
+type Store interface {
+	// Lock claims the key for the record. When the key is already claimed
+	// by a record that hasn't expired, that record is returned instead and
+	// locked is false.
+	Lock(ctx context.Context, rec Record, now time.Time) (existing Record, locked bool, err error)
+
+	// Complete stores the response of the record claiming the key.
+	Complete(ctx context.Context, rec Record) error
+
+	// Release removes the claim of the record, so the key can be used again.
+	Release(ctx context.Context, rec Record) error
+}
@@ -0,0 +1,285 @@
+// Package idempotencydb provides an idempotency store backed by Postgres.
+package idempotencydb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zucchini/services-golang/app/api/idempotency"
+	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+// Schema creates the table holding the records. It's safe to execute it
+// every time the service starts.
+const Schema = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	user_id     TEXT        NOT NULL,
+	key         TEXT        NOT NULL,
+	fingerprint TEXT        NOT NULL,
+	token       TEXT        NOT NULL,
+	status_code INT         NOT NULL DEFAULT 0,
+	header      TEXT        NOT NULL DEFAULT '',
+	body        BYTEA,
+	expires_at  TIMESTAMPTZ NOT NULL,
+
+	PRIMARY KEY (user_id, key)
+)`
+
+// Store manages the set of APIs for idempotency database access.
+type Store struct {
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Migrate creates the table holding the records if it doesn't exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	if err := sqldb.ExecContext(ctx, s.log, s.db, Schema); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	return nil
+}
+
+// Lock claims the key for the record. The expired record of the key is
+// removed first, so an abandoned request doesn't hold the key forever.
+func (s *Store) Lock(ctx context.Context, rec idempotency.Record, now time.Time) (existing idempotency.Record, locked bool, err error) {
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("begin: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	data := struct {
+		UserID string    `db:"user_id"`
+		Key    string    `db:"key"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: rec.UserID,
+		Key:    rec.Key,
+		Now:    now.UTC(),
+	}
+
+	const qDelete = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND key = :key AND expires_at < :now`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, tx, qDelete, data); err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	const qInsert = `
+	INSERT INTO idempotency_keys
+		(user_id, key, fingerprint, token, expires_at)
+	VALUES
+		(:user_id, :key, :fingerprint, :token, :expires_at)
+	ON CONFLICT (user_id, key) DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, tx, qInsert, dbRec); err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	const qSelect = `
+	SELECT
+		user_id, key, fingerprint, token, status_code, header, body, expires_at
+	FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND key = :key`
+
+	var dbExisting record
+	if err := sqldb.NamedQueryStruct(ctx, s.log, tx, qSelect, data, &dbExisting); err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("commit: %w", err)
+	}
+
+	existing, err = toRecord(dbExisting)
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	return existing, existing.Token == rec.Token, nil
+}
+
+// Complete stores the response of the record claiming the key.
+func (s *Store) Complete(ctx context.Context, rec idempotency.Record) error {
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		idempotency_keys
+	SET
+		status_code = :status_code,
+		header = :header,
+		body = :body,
+		expires_at = :expires_at
+	WHERE
+		user_id = :user_id AND key = :key AND token = :token`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbRec); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the claim of the record, so the key can be used again.
+func (s *Store) Release(ctx context.Context, rec idempotency.Record) error {
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		user_id = :user_id AND key = :key AND token = :token`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbRec); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the records that expired before now. Lock only
+// removes the expired record of the key it claims, so the records of the
+// keys that are never used again are removed here.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) error {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		expires_at < :now`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Purge calls DeleteExpired every interval until the context is canceled,
+// so the table doesn't grow without bound. It's meant to run as a
+// background worker of the service. A failed purge is logged and retried
+// on the next interval.
+func (s *Store) Purge(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case now := <-ticker.C:
+			if err := s.DeleteExpired(ctx, now); err != nil && ctx.Err() == nil {
+				s.log.Error(ctx, "idempotency purge", "ERROR", err)
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+// The stored responses can hold credentials, like a token issued to the user,
+// so they aren't logged with the queries.
+type record struct {
+	UserID      string    `db:"user_id"`
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	Token       string    `db:"token"`
+	StatusCode  int       `db:"status_code"`
+	Header      string    `db:"header" log:"sensitive"`
+	Body        []byte    `db:"body" log:"sensitive"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func toDBRecord(rec idempotency.Record) (record, error) {
+	dbRec := record{
+		UserID:      rec.UserID,
+		Key:         rec.Key,
+		Fingerprint: rec.Fingerprint,
+		Token:       rec.Token,
+		ExpiresAt:   rec.ExpiresAt.UTC(),
+	}
+
+	if rec.Response != nil {
+		header, err := json.Marshal(rec.Response.Header)
+		if err != nil {
+			return record{}, fmt.Errorf("marshal header: %w", err)
+		}
+
+		dbRec.StatusCode = rec.Response.StatusCode
+		dbRec.Header = string(header)
+		dbRec.Body = rec.Response.Body
+	}
+
+	return dbRec, nil
+}
+
+func toRecord(dbRec record) (idempotency.Record, error) {
+	rec := idempotency.Record{
+		UserID:      dbRec.UserID,
+		Key:         dbRec.Key,
+		Fingerprint: dbRec.Fingerprint,
+		Token:       dbRec.Token,
+		ExpiresAt:   dbRec.ExpiresAt,
+	}
+
+	// A status code is only stored once the request is completed.
+	if dbRec.StatusCode == 0 {
+		return rec, nil
+	}
+
+	resp := idempotency.Response{
+		StatusCode: dbRec.StatusCode,
+		Body:       dbRec.Body,
+	}
+
+	if dbRec.Header != "" {
+		if err := json.Unmarshal([]byte(dbRec.Header), &resp.Header); err != nil {
+			return idempotency.Record{}, fmt.Errorf("unmarshal header: %w", err)
+		}
+	}
+
+	rec.Response = &resp
+
+	return rec, nil
+}
@@ -0,0 +1,98 @@
+package idempotencydb
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/app/api/idempotency"
+	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+func TestRecord(t *testing.T) {
+	expiresAt := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rec  idempotency.Record
+	}{
+		{
+			name: "in-progress",
+			rec: idempotency.Record{
+				UserID:      "5cf37266-3473-4006-984f-9325122678b7",
+				Key:         "key-1",
+				Fingerprint: "abc",
+				Token:       "token",
+				ExpiresAt:   expiresAt,
+			},
+		},
+		{
+			name: "completed",
+			rec: idempotency.Record{
+				UserID:      "5cf37266-3473-4006-984f-9325122678b7",
+				Key:         "key-1",
+				Fingerprint: "abc",
+				Token:       "token",
+				Response: &idempotency.Response{
+					StatusCode: 201,
+					Header:     map[string][]string{"Location": {"/users/1"}},
+					Body:       []byte(`{"id":1}`),
+				},
+				ExpiresAt: expiresAt,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbRec, err := toDBRecord(tt.rec)
+			if err != nil {
+				t.Fatalf("Should be able to convert the record: %s", err)
+			}
+
+			got, err := toRecord(dbRec)
+			if err != nil {
+				t.Fatalf("Should be able to convert the db record: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.rec) {
+				t.Errorf("Should get the record back:\ngot: %+v\nexp: %+v", got, tt.rec)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	// Nothing listens on the port, so every purge fails and is logged.
+	db, err := sqldb.Open(sqldb.Config{
+		User:       "postgres",
+		Password:   "postgres",
+		HostPort:   "127.0.0.1:1",
+		Name:       "postgres",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to open the database: %s", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelError, "TEST", nil)
+
+	store := NewStore(log, db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := store.Purge(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("Should stop without an error when the context is done: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "idempotency purge") {
+		t.Errorf("Should log the failed purges: %s", buf.String())
+	}
+}
@@ -20,6 +20,7 @@ type metrics struct {
 	requests   *expvar.Int
 	errors     *expvar.Int
 	panics     *expvar.Int
+	bytes      *expvar.Int
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -32,6 +33,7 @@ func init() {
 		requests:   expvar.NewInt("requests"),
 		errors:     expvar.NewInt("errors"),
 		panics:     expvar.NewInt("panics"),
+		bytes:      expvar.NewInt("bytes"),
 	}
 }
 
@@ -85,3 +87,13 @@ func AddPanics(ctx context.Context) int64 {
 
 	return 0
 }
+
+// AddBytes increments the bytes metric by the bytes written to the client.
+func AddBytes(ctx context.Context, n int64) int64 {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.bytes.Add(n)
+		return v.bytes.Value()
+	}
+
+	return 0
+}
@@ -13,9 +13,13 @@ import (
 
 	"github.com/zucchini/services-golang/app/api/errs"
 	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/otel"
 )
 
 func AuthenticateOnServer(ctx context.Context, authClient *authclient.Client, authorization string, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.authenticate")
+	defer span.End()
+
 	resp, err := authClient.Authenticate(ctx, authorization)
 	if err != nil {
 		return errs.New(errs.Unauthenticated, err)
@@ -28,6 +32,9 @@ func AuthenticateOnServer(ctx context.Context, authClient *authclient.Client, au
 }
 
 func AuthenticateLocal(ctx context.Context, a *auth.Auth, authorization string, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.authenticate")
+	defer span.End()
+
 	var err error
 
 	parts := strings.Split(authorization, " ")
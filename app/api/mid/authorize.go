@@ -5,9 +5,14 @@ import (
 
 	"github.com/zucchini/services-golang/app/api/authclient"
 	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/foundation/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func AuthorizeOnService(ctx context.Context, a *authclient.Client, rule string, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.authorize", attribute.String("rule", rule))
+	defer span.End()
+
 	userID, err := GetUserID(ctx)
 	if err != nil {
 		return errs.Newf(errs.Unauthenticated, "authorize: %v", err)
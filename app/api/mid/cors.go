@@ -0,0 +1,153 @@
+package mid
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/otel"
+)
+
+// CORSConfig represents the cross-origin policy of the application.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API. A "*" allows
+	// any origin and a "*." label allows any subdomain, like
+	// "https://*.example.com".
+	AllowedOrigins []string
+
+	// AllowedMethods lists the methods allowed in a preflight request. When
+	// empty, the common methods are allowed.
+	AllowedMethods []string
+
+	// AllowedHeaders lists the headers allowed in a preflight request. When
+	// empty, the headers requested by the client are allowed.
+	AllowedHeaders []string
+
+	// ExposedHeaders lists the response headers the client can read.
+	ExposedHeaders []string
+
+	// AllowCredentials allows the client to send cookies and authorization
+	// headers. The origin is echoed back instead of using "*".
+	AllowCredentials bool
+
+	// MaxAge is how long the client can cache the result of a preflight.
+	MaxAge time.Duration
+}
+
+// CORSRequest represents the cross-origin information of a request.
+type CORSRequest struct {
+	Origin         string
+	Preflight      bool
+	RequestMethod  string
+	RequestHeaders []string
+}
+
+// CORSResult represents what the protocol layer must announce to the
+// client when the cross-origin request is allowed.
+type CORSResult struct {
+	AllowOrigin      string
+	AllowCredentials bool
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	MaxAge           time.Duration
+
+	// VaryOrigin reports the result depends on the origin of the request,
+	// so caches must key the response by origin.
+	VaryOrigin bool
+}
+
+var defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+// CORS evaluates the cross-origin request against the policy. When the
+// request is allowed, the result is handed to the apply function before the
+// handler is executed. Requests that are not allowed are executed without a
+// result, so the client is the one blocking the response.
+func CORS(ctx context.Context, cfg CORSConfig, req CORSRequest, apply func(CORSResult), handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.cors")
+	defer span.End()
+
+	if req.Origin == "" {
+		return handler(ctx)
+	}
+
+	if res, ok := evaluateCORS(cfg, req); ok {
+		apply(res)
+	}
+
+	return handler(ctx)
+}
+
+func evaluateCORS(cfg CORSConfig, req CORSRequest) (CORSResult, bool) {
+	anyOrigin, allowed := matchOrigin(cfg.AllowedOrigins, req.Origin)
+	if !allowed {
+		return CORSResult{}, false
+	}
+
+	res := CORSResult{
+		AllowOrigin:      req.Origin,
+		AllowCredentials: cfg.AllowCredentials,
+		VaryOrigin:       true,
+	}
+
+	// A wildcard origin can't be used with credentials, so the origin is
+	// echoed back in that case.
+	if anyOrigin && !cfg.AllowCredentials {
+		res.AllowOrigin = "*"
+		res.VaryOrigin = false
+	}
+
+	if !req.Preflight {
+		res.ExposeHeaders = cfg.ExposedHeaders
+		return res, true
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+
+	if !slices.ContainsFunc(methods, func(m string) bool { return strings.EqualFold(m, req.RequestMethod) }) {
+		return CORSResult{}, false
+	}
+
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = req.RequestHeaders
+	}
+
+	for _, h := range req.RequestHeaders {
+		if !slices.ContainsFunc(headers, func(a string) bool { return strings.EqualFold(a, h) }) {
+			return CORSResult{}, false
+		}
+	}
+
+	res.AllowMethods = methods
+	res.AllowHeaders = headers
+	res.MaxAge = cfg.MaxAge
+
+	return res, true
+}
+
+// matchOrigin reports if the origin is allowed and if it was allowed by
+// the "*" wildcard.
+func matchOrigin(allowed []string, origin string) (anyOrigin bool, ok bool) {
+	for _, a := range allowed {
+		switch {
+		case a == "*":
+			return true, true
+
+		case strings.EqualFold(a, origin):
+			return false, true
+
+		case strings.Contains(a, "://*."):
+			scheme, host, _ := strings.Cut(a, "://*")
+			if strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, host) && len(origin) > len(scheme+"://"+host) {
+				return false, true
+			}
+		}
+	}
+
+	return false, false
+}
@@ -5,20 +5,40 @@ import (
 	"context"
 
 	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/metrics"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/otel"
+	"github.com/zucchini/services-golang/foundation/validate"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Errors handles errors coming out of the call chain. It detects normal application errors
-// which are used to respond to the client in a uniform way.
+// which are used to respond to the client in a uniform way. The errors are
+// counted here, since the metrics middleware runs first to count the bytes
+// of the error responses.
 func Errors(ctx context.Context, log *logger.Logger, next Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.errors")
+	defer span.End()
+
 	err := next(ctx)
 
 	if err == nil {
 		return nil
 	}
 
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	metrics.AddErrors(ctx)
+
 	log.Error(ctx, "message", "ERROR", err.Error())
 
+	// Validation failures are reported field by field, regardless of the
+	// error the handler wrapped them in.
+	if fe := validate.GetFieldErrors(err); fe != nil {
+		return errs.NewFieldsError(fe, fe.Fields())
+	}
+
 	if errs.IsError(err) {
 		return errs.GetError(err)
 	}
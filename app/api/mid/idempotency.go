@@ -0,0 +1,116 @@
+package mid
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/idempotency"
+	"github.com/zucchini/services-golang/foundation/otel"
+)
+
+// IdempotencyConfig represents how the idempotency keys are kept.
+type IdempotencyConfig struct {
+	// Store keeps the records of the keys.
+	Store idempotency.Store
+
+	// TTL is how long the response of a completed request is replayed.
+	// It defaults to 24 hours.
+	TTL time.Duration
+
+	// LockTimeout is how long a request in progress holds the key before
+	// it's considered abandoned. It defaults to 1 minute.
+	LockTimeout time.Duration
+
+	// MaxBodySize is the largest body of a request with a key, which is
+	// read whole to fingerprint it. It defaults to 1MB.
+	MaxBodySize int64
+}
+
+// IdempotencyRequest represents the idempotency information of a request.
+// The fingerprint identifies the payload, so a key can't be reused for a
+// different request.
+type IdempotencyRequest struct {
+	Key         string
+	Fingerprint string
+}
+
+// Idempotency executes the handler once per key and user. A retry of a
+// completed request is handed the stored response through the replay
+// function, and the response of the handler is obtained through the
+// capture function to be stored. The key is released when the handler
+// fails, so the client can retry the request. The requests must be
+// authenticated.
+func Idempotency(ctx context.Context, cfg IdempotencyConfig, req IdempotencyRequest, replay func(idempotency.Response) error, capture func() idempotency.Response, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.idempotency")
+	defer span.End()
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	lockTimeout := cfg.LockTimeout
+	if lockTimeout <= 0 {
+		lockTimeout = time.Minute
+	}
+
+	// The keys are scoped by user, otherwise the anonymous clients would
+	// share the keys and could be handed the responses of each other.
+	userID, err := GetUserID(ctx)
+	if err != nil {
+		return errs.Newf(errs.Unauthenticated, "idempotency: the requests with a key must be authenticated")
+	}
+
+	now := time.Now()
+
+	rec := idempotency.Record{
+		UserID:      userID.String(),
+		Key:         req.Key,
+		Fingerprint: req.Fingerprint,
+		Token:       uuid.NewString(),
+		ExpiresAt:   now.Add(lockTimeout),
+	}
+
+	existing, locked, err := cfg.Store.Lock(ctx, rec, now)
+	if err != nil {
+		return errs.Newf(errs.Internal, "idempotency: lock: %s", err)
+	}
+
+	if !locked {
+		switch {
+		case existing.Fingerprint != req.Fingerprint:
+			return errs.Newf(errs.AlreadyExists, "idempotency: key %q was used for a different request", req.Key)
+
+		case existing.Response == nil:
+			return errs.Newf(errs.Aborted, "idempotency: a request with key %q is in progress", req.Key)
+		}
+
+		return replay(*existing.Response)
+	}
+
+	// The record must be updated even when the client went away, otherwise
+	// the key stays locked until the lock times out.
+	storeCtx := context.WithoutCancel(ctx)
+
+	if err := handler(ctx); err != nil {
+		if err := cfg.Store.Release(storeCtx, rec); err != nil {
+			span.RecordError(err)
+		}
+
+		return err
+	}
+
+	resp := capture()
+	rec.Response = &resp
+	rec.ExpiresAt = now.Add(ttl)
+
+	// The response already reached the client, so failing to store it only
+	// means a retry executes the request again once the lock times out.
+	if err := cfg.Store.Complete(storeCtx, rec); err != nil {
+		span.RecordError(err)
+	}
+
+	return nil
+}
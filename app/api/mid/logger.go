@@ -4,11 +4,19 @@ import (
 	"context"
 
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/otel"
 	"github.com/zucchini/services-golang/foundation/web"
 )
 
 // Logger is a middleware that logs information about the request to the logs.
-func Logger(ctx context.Context, log *logger.Logger, path, rawQuery, method, remoteAddr string, handler Handler) error {
+// The route is added to every log of the request.
+func Logger(ctx context.Context, log *logger.Logger, route, path, rawQuery, method, remoteAddr string, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.logger")
+	defer span.End()
+
+	if route != "" {
+		ctx = logger.AddAttrs(ctx, "route", route)
+	}
 
 	values := web.GetValues(ctx)
 
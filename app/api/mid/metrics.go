@@ -4,14 +4,22 @@ import (
 	"context"
 
 	"github.com/zucchini/services-golang/app/api/metrics"
+	"github.com/zucchini/services-golang/foundation/otel"
 )
 
-func Metrics(ctx context.Context, handler Handler) error {
+// Metrics updates the metrics of the application once the request is
+// handled. The written function reports the bytes of the response, which
+// the protocol layer counts. The errors are counted by the Errors
+// middleware, which runs after this one.
+func Metrics(ctx context.Context, written func() int64, handler Handler) error {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.metrics")
+	defer span.End()
 
 	ctx = metrics.Set(ctx)
 	err := handler(ctx)
 
 	requests := metrics.AddRequests(ctx)
+	metrics.AddBytes(ctx, written())
 
 	if requests%5000 == 0 {
 		// This is a hack to get the number of goroutines.
@@ -21,9 +29,5 @@ func Metrics(ctx context.Context, handler Handler) error {
 		metrics.AddGoroutines(ctx)
 	}
 
-	if err != nil {
-		metrics.AddErrors(ctx)
-	}
-
 	return err
 }
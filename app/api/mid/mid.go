@@ -14,6 +14,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/zucchini/services-golang/business/api/auth"
+	"github.com/zucchini/services-golang/foundation/logger"
 )
 
 // Handler is a function that takes a context and returns an error.
@@ -42,7 +43,9 @@ func GetClaims(ctx context.Context) auth.Claims {
 	return v
 }
 
+// setUserID also adds the user id to the logs of the request.
 func setUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	ctx = logger.AddAttrs(ctx, "user_id", userID)
 	return context.WithValue(ctx, userIDKey, userID)
 }
 
@@ -6,11 +6,15 @@ import (
 	"runtime/debug"
 
 	"github.com/zucchini/services-golang/app/api/metrics"
+	"github.com/zucchini/services-golang/foundation/otel"
 )
 
 // Panics is a middleware that recovers from panics and returns an error so it
 // can be reported in Metrics and handled in Errors
 func Panics(ctx context.Context, handler Handler) (err error) {
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.panics")
+	defer span.End()
+
 	defer func() {
 		if r := recover(); r != nil {
 			trace := debug.Stack()
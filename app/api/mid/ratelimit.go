@@ -0,0 +1,40 @@
+package mid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/zucchini/services-golang/app/api/errs"
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+	"github.com/zucchini/services-golang/foundation/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RateLimit takes a request from the bucket of the key. The result is handed
+// to the apply function so the protocol layer can announce the limit to the
+// client. When the store fails, the request is let through so an outage of
+// the store doesn't take the service down with it. The key can hold a
+// credential, like an API key, so it's hashed before it's traced or stored.
+func RateLimit(ctx context.Context, store ratelimit.Store, limit ratelimit.Limit, key string, apply func(ratelimit.Result), handler Handler) error {
+	sum := sha256.Sum256([]byte(key))
+	key = hex.EncodeToString(sum[:])
+
+	ctx, span := otel.AddSpan(ctx, "app.api.mid.ratelimit", attribute.String("key", key))
+	defer span.End()
+
+	res, err := store.Take(ctx, key, limit, time.Now())
+	if err != nil {
+		span.RecordError(err)
+		return handler(ctx)
+	}
+
+	apply(res)
+
+	if !res.Allowed {
+		return errs.Newf(errs.ResourceExhausted, "ratelimit: too many requests, retry in %ds", ratelimit.Seconds(res.RetryAfter))
+	}
+
+	return handler(ctx)
+}
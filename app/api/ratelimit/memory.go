@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store discards the buckets that
+// are full again.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in memory. The limits are not shared across
+// replicas of the service.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	bucket Bucket
+	limit  Limit
+}
+
+// NewMemoryStore constructs a store that keeps the buckets in memory.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]entry),
+	}
+}
+
+// Take applies the request to the bucket of the key.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	e, exists := s.buckets[key]
+	if !exists {
+		e = entry{bucket: NewBucket(limit, now)}
+	}
+
+	var res Result
+	e.bucket, res = e.bucket.Take(limit, now)
+	e.limit = limit
+
+	s.buckets[key] = e
+
+	return res, nil
+}
+
+// sweep discards the buckets that are full, so idle keys don't hold memory.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.buckets {
+		if e.bucket.Full(e.limit, now) {
+			delete(s.buckets, key)
+		}
+	}
+}
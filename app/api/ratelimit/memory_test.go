@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name  string
+		since time.Duration
+		kept  bool
+	}{
+		{name: "within-interval", since: 30 * time.Second, kept: true},
+		{name: "refilled", since: sweepInterval, kept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			ctx := context.Background()
+
+			if _, err := store.Take(ctx, "idle", limit, now); err != nil {
+				t.Fatalf("Should take from the bucket: %s", err)
+			}
+
+			if _, err := store.Take(ctx, "active", limit, now.Add(tt.since)); err != nil {
+				t.Fatalf("Should take from the bucket: %s", err)
+			}
+
+			if _, exists := store.buckets["idle"]; exists != tt.kept {
+				t.Errorf("Should keep the idle bucket %t, got %t", tt.kept, exists)
+			}
+
+			if _, exists := store.buckets["active"]; !exists {
+				t.Error("Should keep the bucket in use")
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweepPartial(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+
+	// A token every 10 seconds, so the bucket isn't full by the sweep.
+	limit := Limit{Requests: 10, Period: 100 * time.Second}
+
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for range 10 {
+		store.Take(ctx, "drained", limit, now)
+	}
+
+	store.Take(ctx, "other", limit, now.Add(sweepInterval))
+
+	if _, exists := store.buckets["drained"]; !exists {
+		t.Error("Should keep the bucket that isn't full yet")
+	}
+}
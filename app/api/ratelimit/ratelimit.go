@@ -0,0 +1,128 @@
+// Package ratelimit provides support for limiting the rate of requests using
+// a token bucket. The state of the buckets is kept in a Store, so the limits
+// can be shared across replicas of a service.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit represents the number of requests allowed in a period. Burst is the
+// capacity of the bucket, which defaults to the number of requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled reports if the limit allows a finite number of requests.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Capacity returns the maximum number of tokens of the bucket.
+func (l Limit) Capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+
+	return float64(l.Requests)
+}
+
+// Rate returns the number of tokens added to the bucket per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// RefillTime returns how long an empty bucket takes to be full again. A
+// bucket that wasn't used for that long holds no information.
+func (l Limit) RefillTime() time.Duration {
+	return seconds(l.Capacity() / l.Rate())
+}
+
+// Result represents the state of the bucket after a request is taken.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store defines the behavior required to keep the buckets. Take must apply
+// the request to the bucket of the key atomically.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// =============================================================================
+
+// Bucket represents the state of a token bucket. It's exported so stores
+// outside of this package share the same math.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewBucket returns a full bucket for the limit.
+func NewBucket(limit Limit, now time.Time) Bucket {
+	return Bucket{
+		Tokens:    limit.Capacity(),
+		UpdatedAt: now,
+	}
+}
+
+// Take refills the bucket for the time elapsed since its last update and
+// takes a token from it if there is one available.
+func (b Bucket) Take(limit Limit, now time.Time) (Bucket, Result) {
+	if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(limit.Capacity(), b.Tokens+elapsed*limit.Rate())
+	}
+	b.UpdatedAt = now
+
+	allowed := b.Tokens >= 1
+	if allowed {
+		b.Tokens--
+	}
+
+	return b, NewResult(limit, b.Tokens, allowed)
+}
+
+// NewResult returns the result of a request from the tokens left in the
+// bucket once the request was applied. It's exported so stores applying the
+// math of Take on their own, like in a query, report the same result.
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	capacity := limit.Capacity()
+	rate := limit.Rate()
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(capacity),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((capacity - tokens) / rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return res
+}
+
+// Full reports if the bucket would be full at the time provided, in which
+// case it holds no information and can be discarded.
+func (b Bucket) Full(limit Limit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.UpdatedAt).Seconds()*limit.Rate() >= limit.Capacity()
+}
+
+// Seconds returns the duration in whole seconds, rounded up so a client
+// waiting for that long always finds a token.
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
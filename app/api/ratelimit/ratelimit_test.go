@@ -0,0 +1,136 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+)
+
+func TestBucketTake(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+
+	// 10 requests per 10 seconds, so a token is added every second.
+	limit := ratelimit.Limit{Requests: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name   string
+		limit  ratelimit.Limit
+		tokens float64
+		since  time.Duration
+		exp    ratelimit.Result
+	}{
+		{
+			name:   "full",
+			limit:  limit,
+			tokens: 10,
+			exp:    ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+		},
+		{
+			name:   "last-token",
+			limit:  limit,
+			tokens: 1,
+			exp:    ratelimit.Result{Allowed: true, Limit: 10, Remaining: 0, Reset: 10 * time.Second},
+		},
+		{
+			name:   "empty",
+			limit:  limit,
+			tokens: 0,
+			exp:    ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second},
+		},
+		{
+			name:   "partial-token",
+			limit:  limit,
+			tokens: 0.25,
+			exp:    ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 9750 * time.Millisecond, RetryAfter: 750 * time.Millisecond},
+		},
+		{
+			name:   "refill",
+			limit:  limit,
+			tokens: 0,
+			since:  3 * time.Second,
+			exp:    ratelimit.Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 8 * time.Second},
+		},
+		{
+			name:   "refill-capped",
+			limit:  limit,
+			tokens: 5,
+			since:  time.Hour,
+			exp:    ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second},
+		},
+		{
+			name:   "clock-skew",
+			limit:  limit,
+			tokens: 0,
+			since:  -time.Second,
+			exp:    ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second},
+		},
+		{
+			name:   "burst",
+			limit:  ratelimit.Limit{Requests: 10, Period: 10 * time.Second, Burst: 20},
+			tokens: 20,
+			exp:    ratelimit.Result{Allowed: true, Limit: 20, Remaining: 19, Reset: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bkt := ratelimit.Bucket{
+				Tokens:    tt.tokens,
+				UpdatedAt: now.Add(-tt.since),
+			}
+
+			bkt, res := bkt.Take(tt.limit, now)
+
+			if res != tt.exp {
+				t.Errorf("Should get the result:\ngot: %+v\nexp: %+v", res, tt.exp)
+			}
+
+			if !bkt.UpdatedAt.Equal(now) {
+				t.Errorf("Should update the bucket at %s, got %s", now, bkt.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestBucketDrain(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 10, 0, 0, 0, time.UTC)
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute, Burst: 3}
+
+	bkt := ratelimit.NewBucket(limit, now)
+
+	var res ratelimit.Result
+	for i := range 3 {
+		if bkt, res = bkt.Take(limit, now); !res.Allowed {
+			t.Fatalf("Should allow request %d within the burst", i)
+		}
+	}
+
+	if bkt, res = bkt.Take(limit, now); res.Allowed {
+		t.Fatal("Should deny the request once the burst is used")
+	}
+
+	if got := ratelimit.Seconds(res.RetryAfter); got != 30 {
+		t.Errorf("Should retry after 30 seconds, got %d", got)
+	}
+
+	if _, res = bkt.Take(limit, now.Add(res.RetryAfter)); !res.Allowed {
+		t.Error("Should allow the request after the retry period")
+	}
+}
+
+func TestLimitRefillTime(t *testing.T) {
+	tests := []struct {
+		limit ratelimit.Limit
+		exp   time.Duration
+	}{
+		{limit: ratelimit.Limit{Requests: 100, Period: time.Minute}, exp: time.Minute},
+		{limit: ratelimit.Limit{Requests: 100, Period: time.Minute, Burst: 50}, exp: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.RefillTime(); got != tt.exp {
+			t.Errorf("Should refill %+v in %s, got %s", tt.limit, tt.exp, got)
+		}
+	}
+}
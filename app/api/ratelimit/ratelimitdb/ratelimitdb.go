@@ -0,0 +1,149 @@
+// Package ratelimitdb provides a rate limit store backed by Postgres, so the
+// limits are shared across replicas of a service.
+package ratelimitdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zucchini/services-golang/app/api/ratelimit"
+	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+// Schema creates the table holding the buckets. It's safe to execute it
+// every time the service starts.
+const Schema = `
+CREATE TABLE IF NOT EXISTS rate_limits (
+	key        TEXT             NOT NULL,
+	tokens     DOUBLE PRECISION NOT NULL,
+	allowed    BOOLEAN          NOT NULL,
+	updated_at TIMESTAMPTZ      NOT NULL,
+
+	PRIMARY KEY (key)
+)`
+
+// Store manages the set of APIs for rate limit database access.
+type Store struct {
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Migrate creates the table holding the buckets if it doesn't exist.
+func (s *Store) Migrate(ctx context.Context) error {
+	if err := sqldb.ExecContext(ctx, s.log, s.db, Schema); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	return nil
+}
+
+// Take applies the request to the bucket of the key. The bucket is refilled
+// and the token taken by a single upsert, which locks the row, so concurrent
+// requests from different replicas are applied one after the other. The
+// math is the one of ratelimit.Bucket.
+func (s *Store) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	data := struct {
+		Key      string    `db:"key"`
+		Capacity float64   `db:"capacity"`
+		Rate     float64   `db:"rate"`
+		Now      time.Time `db:"now"`
+	}{
+		Key:      key,
+		Capacity: limit.Capacity(),
+		Rate:     limit.Rate(),
+		Now:      now.UTC(),
+	}
+
+	const q = `
+	INSERT INTO rate_limits AS rl
+		(key, tokens, allowed, updated_at)
+	VALUES
+		(:key, CAST(:capacity AS DOUBLE PRECISION) - 1, TRUE, :now)
+	ON CONFLICT (key) DO UPDATE SET
+		(tokens, allowed) = (
+			SELECT
+				CASE WHEN r.tokens >= 1 THEN r.tokens - 1 ELSE r.tokens END,
+				r.tokens >= 1
+			FROM (
+				SELECT
+					LEAST(
+						CAST(:capacity AS DOUBLE PRECISION),
+						rl.tokens + GREATEST(CAST(EXTRACT(EPOCH FROM CAST(:now AS TIMESTAMPTZ) - rl.updated_at) AS DOUBLE PRECISION), 0) * CAST(:rate AS DOUBLE PRECISION)
+					) AS tokens
+			) AS r
+		),
+		updated_at = GREATEST(rl.updated_at, CAST(:now AS TIMESTAMPTZ))
+	RETURNING
+		key, tokens, allowed, updated_at`
+
+	var dbBkt bucket
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbBkt); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return ratelimit.NewResult(limit, dbBkt.Tokens, dbBkt.Allowed), nil
+}
+
+// DeleteIdle removes the buckets that weren't used for the refill time of
+// the limit, which are full again and hold no information. The limit must be
+// the one with the longest refill time of the limits sharing the store.
+func (s *Store) DeleteIdle(ctx context.Context, limit ratelimit.Limit, now time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: now.Add(-limit.RefillTime()).UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		rate_limits
+	WHERE
+		updated_at < :before`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Purge calls DeleteIdle every interval until the context is canceled, so
+// the table doesn't keep a row for every client forever. It's meant to run
+// as a background worker of the service. A failed purge is logged and
+// retried on the next interval.
+func (s *Store) Purge(ctx context.Context, limit ratelimit.Limit, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case now := <-ticker.C:
+			if err := s.DeleteIdle(ctx, limit, now); err != nil && ctx.Err() == nil {
+				s.log.Error(ctx, "rate limit purge", "ERROR", err)
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+type bucket struct {
+	Key       string    `db:"key"`
+	Tokens    float64   `db:"tokens"`
+	Allowed   bool      `db:"allowed"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
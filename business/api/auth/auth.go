@@ -133,8 +133,6 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 		"ISS":   a.issuer,
 	}
 
-	fmt.Printf("input: %+v\n", input)
-
 	if err := opaPolicyEvaluation(ctx, regoScriptAuthentication, RuleAuthenticate, input); err != nil {
 		return Claims{}, fmt.Errorf("unable to evaluate authentication policy: %w", err)
 	}
@@ -163,14 +161,33 @@ func (a *Auth) Authorize(ctx context.Context, claims Claims, userID uuid.UUID, r
 	return nil
 }
 
-func opaPolicyEvaluation(ctx context.Context, regoScript string, rule string, input map[string]any) error {
+// CheckPolicies compiles the policy of every rule, so a broken policy is
+// reported by the health checks before a request needs it.
+func (a *Auth) CheckPolicies(ctx context.Context) error {
+	if _, err := preparePolicy(ctx, regoScriptAuthentication, RuleAuthenticate); err != nil {
+		return fmt.Errorf("rule %s: %w", RuleAuthenticate, err)
+	}
 
+	for _, rule := range []string{RuleAny, RuleAdminOnly, RuleUserOnly, RuleAdminOrSubject} {
+		if _, err := preparePolicy(ctx, regoScriptAuthorization, rule); err != nil {
+			return fmt.Errorf("rule %s: %w", rule, err)
+		}
+	}
+
+	return nil
+}
+
+func preparePolicy(ctx context.Context, regoScript string, rule string) (rego.PreparedEvalQuery, error) {
 	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
 
-	q, err := rego.New(
+	return rego.New(
 		rego.Query(query),
 		rego.Module("policy.rego", regoScript),
 	).PrepareForEval(ctx)
+}
+
+func opaPolicyEvaluation(ctx context.Context, regoScript string, rule string, input map[string]any) error {
+	q, err := preparePolicy(ctx, regoScript, rule)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,96 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+type credentials struct {
+	PasswordHash []byte `db:"password_hash" log:"sensitive"`
+}
+
+type user struct {
+	credentials
+	ID    int    `db:"user_id"`
+	Email string `db:"email"`
+}
+
+type product struct {
+	ID   int    `db:"product_id"`
+	Name string `db:"name"`
+}
+
+type apiKey struct {
+	ID  int    `db:"key_id"`
+	Key string `db:"key" log:"sensitive"`
+}
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		args  any
+		exp   string
+	}{
+		{
+			name:  "sensitive",
+			query: "UPDATE api_keys SET key = :key WHERE key_id = :key_id",
+			args:  apiKey{ID: 1, Key: "secret"},
+			exp:   "UPDATE api_keys SET key = '[REDACTED]' WHERE key_id = 1",
+		},
+		{
+			name:  "pointer",
+			query: "UPDATE api_keys SET key = :key WHERE key_id = :key_id",
+			args:  &apiKey{ID: 1, Key: "secret"},
+			exp:   "UPDATE api_keys SET key = '[REDACTED]' WHERE key_id = 1",
+		},
+		{
+			name:  "embedded",
+			query: "UPDATE users SET password_hash = :password_hash, email = :email WHERE user_id = :user_id",
+			args:  user{credentials: credentials{PasswordHash: []byte("hash")}, ID: 1, Email: "bill@example.com"},
+			exp:   "UPDATE users SET password_hash = '[REDACTED]', email = 'bill@example.com' WHERE user_id = 1",
+		},
+		{
+			name:  "map",
+			query: "SELECT * FROM users WHERE email = :email",
+			args:  map[string]any{"email": "bill@example.com"},
+			exp:   "SELECT * FROM users WHERE email = 'bill@example.com'",
+		},
+		{
+			name:  "no-args",
+			query: "SELECT 1",
+			args:  struct{}{},
+			exp:   "SELECT 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryString(tt.query, tt.args); got != tt.exp {
+				t.Errorf("Should log the query:\ngot: %s\nexp: %s", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	m := map[string]any{"key": "secret"}
+
+	tests := []struct {
+		name string
+		args any
+	}{
+		{name: "int", args: 42},
+		{name: "string", args: "secret"},
+		{name: "map", args: m},
+		{name: "no-sensitive-field", args: product{ID: 1, Name: "shirt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactArgs(tt.args); !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("Should pass the args through, got %v", got)
+			}
+		})
+	}
+}
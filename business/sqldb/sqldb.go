@@ -7,13 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 	"github.com/zucchini/services-golang/foundation/logger"
+	"github.com/zucchini/services-golang/foundation/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 ) // lib/pq errorCodeNames
 
 // https://github.com/lib/pq/blob/master/error.go#L178
@@ -25,6 +29,10 @@ const (
 
 // Open knows how to open a database connection based on the configuration.
 
+// Source is the name the queries are logged under, so their level can be
+// set with the sqldb source whatever package runs them.
+const Source = "sqldb"
+
 // Set of error variables for CRUD operations.
 var (
 	ErrDBNotFound        = sql.ErrNoRows
@@ -120,14 +128,25 @@ func ExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, qu
 func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (err error) {
 	q := queryString(query, data)
 
+	// The span only records the named query so parameter values are never
+	// sent to the tracing system.
+	ctx, span := otel.AddSpan(ctx, "business.sqldb.exec", semconv.DBQueryText(query))
+	defer span.End()
+
+	// The queries are logged at the debug level, and the failed ones at the
+	// info level, so the sqldb source can be turned up to trace them all.
 	defer func() {
+		caller := 5
+		if _, ok := data.(struct{}); ok {
+			caller = 6
+		}
+
 		if err != nil {
-			if _, ok := data.(struct{}); ok {
-				log.Infoc(ctx, 6, "database.NamedExecContext", "query", q, "ERROR", err)
-			} else {
-				log.Infoc(ctx, 5, "database.NamedExecContext", "query", q, "ERROR", err)
-			}
+			log.Source(Source).Infoc(ctx, caller, "database.NamedExecContext", "query", q, "ERROR", err)
+			return
 		}
+
+		log.Source(Source).Debugc(ctx, caller, "database.NamedExecContext", "query", q)
 	}()
 
 	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
@@ -169,10 +188,16 @@ func NamedQuerySliceUsingIn[T any](ctx context.Context, log *logger.Logger, db s
 func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest *[]T, withIn bool) (err error) {
 	q := queryString(query, data)
 
+	ctx, span := otel.AddSpan(ctx, "business.sqldb.queryslice", semconv.DBQueryText(query))
+	defer span.End()
+
 	defer func() {
 		if err != nil {
-			log.Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
+			log.Source(Source).Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
+			return
 		}
+
+		log.Source(Source).Debugc(ctx, 6, "database.NamedQuerySlice", "query", q)
 	}()
 
 	var rows *sqlx.Rows
@@ -203,7 +228,7 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 		if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
 			return ErrUndefinedTable
 		}
-		
+
 		return err
 	}
 	defer rows.Close()
@@ -243,10 +268,16 @@ func NamedQueryStructUsingIn(ctx context.Context, log *logger.Logger, db sqlx.Ex
 func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, dest any, withIn bool) (err error) {
 	q := queryString(query, data)
 
+	ctx, span := otel.AddSpan(ctx, "business.sqldb.querystruct", semconv.DBQueryText(query))
+	defer span.End()
+
 	defer func() {
 		if err != nil {
-			log.Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
+			log.Source(Source).Infoc(ctx, 6, "database.NamedQuerySlice", "query", q, "ERROR", err)
+			return
 		}
+
+		log.Source(Source).Debugc(ctx, 6, "database.NamedQuerySlice", "query", q)
 	}()
 
 	var rows *sqlx.Rows
@@ -292,8 +323,9 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 }
 
 // queryString provides a pretty print version of the query and parameters.
+// The values of the fields tagged `log:"sensitive"` are masked.
 func queryString(query string, args any) string {
-	query, params, err := sqlx.Named(query, args)
+	query, params, err := sqlx.Named(query, redactArgs(args))
 	if err != nil {
 		return err.Error()
 	}
@@ -316,3 +348,40 @@ func queryString(query string, args any) string {
 
 	return strings.Trim(query, " ")
 }
+
+// mapper maps the fields of the args to their names the same way sqlx does.
+var mapper = reflectx.NewMapperFunc("db", sqlx.NameMapper)
+
+// redactArgs returns the args of a query with the values of the fields tagged
+// `log:"sensitive"` masked, like a password hash. Args that aren't structs,
+// or don't have any such field, are returned as is.
+func redactArgs(args any) any {
+	v := reflect.Indirect(reflect.ValueOf(args))
+	if v.Kind() != reflect.Struct {
+		return args
+	}
+
+	var sensitive []string
+	for name, fi := range mapper.TypeMap(v.Type()).Names {
+		if fi.Field.Tag.Get("log") == "sensitive" {
+			sensitive = append(sensitive, name)
+		}
+	}
+
+	if len(sensitive) == 0 {
+		return args
+	}
+
+	m := make(map[string]any)
+	for name, fv := range mapper.FieldMap(v) {
+		if fv.CanInterface() {
+			m[name] = fv.Interface()
+		}
+	}
+
+	for _, name := range sensitive {
+		m[name] = logger.Redacted
+	}
+
+	return m
+}
@@ -0,0 +1,110 @@
+package sqldb_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zucchini/services-golang/business/sqldb"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+func TestSourceLevel(t *testing.T) {
+	// Nothing listens on the port, so every query fails and is logged.
+	db, err := sqldb.Open(sqldb.Config{
+		User:       "postgres",
+		Password:   "postgres",
+		HostPort:   "127.0.0.1:1",
+		Name:       "postgres",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to open the database: %s", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelWarn, "TEST", nil)
+
+	ctx := context.Background()
+
+	if err := sqldb.ExecContext(ctx, log, db, "SELECT 1"); err == nil {
+		t.Fatal("Should fail to execute the query.")
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Should not log the failed query at the warn level: %s", buf.String())
+	}
+
+	log.SetSourceLevel(ctx, sqldb.Source, logger.LevelDebug)
+	buf.Reset()
+
+	if err := sqldb.ExecContext(ctx, log, db, "SELECT 1"); err == nil {
+		t.Fatal("Should fail to execute the query.")
+	}
+
+	if !strings.Contains(buf.String(), `"msg":"database.NamedExecContext"`) {
+		t.Fatalf("Should log the failed query at the level of the sqldb source: %s", buf.String())
+	}
+
+	// The record is still attributed to the caller of sqldb.
+	if !strings.Contains(buf.String(), `"file":"sqldb_test.go:`) {
+		t.Errorf("Should log the file of the caller: %s", buf.String())
+	}
+}
+
+func TestQueryLevel(t *testing.T) {
+	db, err := sqldb.Open(sqldb.Config{
+		User:       "postgres",
+		Password:   "postgres",
+		HostPort:   "127.0.0.1:1",
+		Name:       "postgres",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to open the database: %s", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", nil)
+
+	ctx := context.Background()
+	exec := execDB{DB: db}
+
+	if err := sqldb.NamedExecContext(ctx, log, exec, "DELETE FROM users WHERE user_id = :id", map[string]any{"id": 1}); err != nil {
+		t.Fatalf("Should be able to execute the query: %s", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Should not log the query at the info level: %s", buf.String())
+	}
+
+	log.SetSourceLevel(ctx, sqldb.Source, logger.LevelDebug)
+	buf.Reset()
+
+	if err := sqldb.NamedExecContext(ctx, log, exec, "DELETE FROM users WHERE user_id = :id", map[string]any{"id": 1}); err != nil {
+		t.Fatalf("Should be able to execute the query: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), `"level":"DEBUG"`) || !strings.Contains(buf.String(), `"query":"DELETE FROM users WHERE user_id = 1"`) {
+		t.Fatalf("Should log the query at the debug level: %s", buf.String())
+	}
+
+	if !strings.Contains(buf.String(), `"file":"sqldb_test.go:`) {
+		t.Errorf("Should log the file of the caller: %s", buf.String())
+	}
+}
+
+// execDB executes every statement successfully without a database.
+type execDB struct {
+	*sqlx.DB
+}
+
+func (execDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return driver.RowsAffected(0), nil
+}
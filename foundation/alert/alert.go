@@ -0,0 +1,318 @@
+// Package alert provides support for notifying about the errors and warnings
+// logged by a service.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+// Alert represents the occurrences of a log record, identified by its level,
+// message and caller, since the last time it was notified.
+type Alert struct {
+	Level      logger.Level   `json:"level"`
+	Message    string         `json:"message"`
+	Caller     string         `json:"caller"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Count      int            `json:"count"`
+	FirstSeen  time.Time      `json:"first_seen"`
+	LastSeen   time.Time      `json:"last_seen"`
+}
+
+// Sink delivers a batch of alerts, like to a webhook.
+type Sink interface {
+	Send(ctx context.Context, alerts []Alert) error
+}
+
+// Config represents the settings of the processing of the alerts.
+type Config struct {
+	Sinks []Sink
+
+	// Window is the period an alert isn't notified again, while its new
+	// occurrences are counted. The default is a minute.
+	Window time.Duration
+
+	// Burst is the number of alerts notified per window. Over it, the
+	// alerts are throttled and notified as a count. The default is 20.
+	Burst int
+
+	// BatchSize and FlushInterval bound how many alerts are sent at once
+	// and how long they wait to be sent. The defaults are 50 and 10s.
+	BatchSize     int
+	FlushInterval time.Duration
+
+	// SendTimeout bounds the delivery of a batch to a sink. The default is
+	// 10s.
+	SendTimeout time.Duration
+
+	// OnError is called when a sink fails. It must not log at a level sent
+	// to the dispatcher, to not feed its own failures back.
+	OnError func(err error)
+}
+
+// Dispatcher receives the records of the log events and notifies the sinks.
+type Dispatcher struct {
+	records  chan logger.Record
+	dropped  atomic.Int64
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// New constructs a Dispatcher queuing up to queueSize records until they are
+// processed by Run.
+func New(queueSize int) *Dispatcher {
+	return &Dispatcher{
+		records: make(chan logger.Record, queueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Notify queues the record. It never blocks the logging, the record is
+// dropped when the queue is full. It can be used as a logger.EventFn.
+func (d *Dispatcher) Notify(ctx context.Context, r logger.Record) {
+	select {
+	case d.records <- r:
+	default:
+		d.dropped.Add(1)
+	}
+}
+
+// Run processes the records until Shutdown is called or the context is
+// done, then flushes the pending alerts. It must be called once.
+func (d *Dispatcher) Run(ctx context.Context, cfg Config) error {
+	defer close(d.done)
+
+	p := newProcessor(cfg)
+
+	ticker := time.NewTicker(p.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case r := <-d.records:
+			if p.add(r, time.Now()) {
+				p.flush(ctx, d.dropped.Swap(0))
+			}
+
+		case <-ticker.C:
+			p.flush(ctx, d.dropped.Swap(0))
+
+		case <-d.stop:
+			d.drain(p)
+			p.flush(context.WithoutCancel(ctx), d.dropped.Swap(0))
+			return nil
+
+		case <-ctx.Done():
+			d.drain(p)
+			p.flush(context.WithoutCancel(ctx), d.dropped.Swap(0))
+			return nil
+		}
+	}
+}
+
+// Shutdown stops Run and waits for the pending alerts to be flushed.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("alert: flush pending alerts: %w", ctx.Err())
+	}
+}
+
+// drain adds the records still queued.
+func (d *Dispatcher) drain(p *processor) {
+	for {
+		select {
+		case r := <-d.records:
+			p.add(r, time.Now())
+		default:
+			return
+		}
+	}
+}
+
+// =============================================================================
+
+// entry tracks an alert between its notifications.
+type entry struct {
+	alert    Alert
+	notified time.Time
+	pending  bool
+}
+
+// processor deduplicates, throttles and batches the alerts. It's only used
+// by the goroutine running the dispatcher.
+type processor struct {
+	cfg         Config
+	entries     map[string]*entry
+	batch       []*entry
+	windowStart time.Time
+	sent        int
+	throttled   int
+}
+
+func newProcessor(cfg Config) *processor {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+
+	if cfg.Burst <= 0 {
+		cfg.Burst = 20
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = 10 * time.Second
+	}
+
+	return &processor{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+	}
+}
+
+// add records an occurrence of the record and reports if the batch is full.
+func (p *processor) add(r logger.Record, now time.Time) bool {
+	if now.Sub(p.windowStart) >= p.cfg.Window {
+		p.windowStart = now
+		p.sent = 0
+
+		// Forget the alerts that weren't seen for a whole window, so the
+		// map doesn't grow with every message ever logged.
+		for key, e := range p.entries {
+			if !e.pending && now.Sub(e.alert.LastSeen) >= p.cfg.Window {
+				delete(p.entries, key)
+			}
+		}
+	}
+
+	key := fmt.Sprintf("%d|%s|%s", r.Level, r.Message, r.Caller)
+
+	e, exists := p.entries[key]
+	if !exists {
+		e = &entry{
+			alert: Alert{
+				Level:   r.Level,
+				Message: r.Message,
+				Caller:  r.Caller,
+			},
+		}
+		p.entries[key] = e
+	}
+
+	if e.alert.Count == 0 {
+		e.alert.FirstSeen = r.Time
+	}
+	e.alert.Count++
+	e.alert.LastSeen = r.Time
+	e.alert.Attributes = attributes(r.Attributes)
+
+	switch {
+	case e.pending:
+		return false
+
+	case !e.notified.IsZero() && now.Sub(e.notified) < p.cfg.Window:
+		return false
+
+	case p.sent >= p.cfg.Burst:
+		p.throttled++
+		return false
+	}
+
+	p.sent++
+	e.pending = true
+	p.batch = append(p.batch, e)
+
+	return len(p.batch) >= p.cfg.BatchSize
+}
+
+// attributes returns the attributes ready to be encoded, since errors are
+// encoded as empty objects.
+func attributes(attrs map[string]any) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for k, v := range attrs {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		m[k] = v
+	}
+
+	return m
+}
+
+// flush sends the batch to the sinks, along with the alerts that were
+// throttled or dropped since the last flush.
+func (p *processor) flush(ctx context.Context, dropped int64) {
+	now := time.Now()
+
+	// Send the alerts seen again during their window once it's over.
+	for _, e := range p.entries {
+		if !e.pending && e.alert.Count > 0 && !e.notified.IsZero() && now.Sub(e.notified) >= p.cfg.Window && p.sent < p.cfg.Burst {
+			p.sent++
+			e.pending = true
+			p.batch = append(p.batch, e)
+		}
+	}
+
+	alerts := make([]Alert, 0, len(p.batch)+1)
+	for _, e := range p.batch {
+		alerts = append(alerts, e.alert)
+
+		e.pending = false
+		e.notified = now
+		e.alert.Count = 0
+	}
+	p.batch = p.batch[:0]
+
+	if n := int64(p.throttled) + dropped; n > 0 {
+		alerts = append(alerts, Alert{
+			Level:     logger.LevelWarn,
+			Message:   "alerts throttled",
+			Caller:    "alert",
+			Count:     int(n),
+			FirstSeen: now,
+			LastSeen:  now,
+		})
+		p.throttled = 0
+	}
+
+	if len(alerts) == 0 {
+		return
+	}
+
+	for _, sink := range p.cfg.Sinks {
+		if err := p.send(ctx, sink, alerts); err != nil && p.cfg.OnError != nil {
+			p.cfg.OnError(err)
+		}
+	}
+}
+
+func (p *processor) send(ctx context.Context, sink Sink, alerts []Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.SendTimeout)
+	defer cancel()
+
+	if err := sink.Send(ctx, alerts); err != nil {
+		return fmt.Errorf("alert: send %d alerts: %w", len(alerts), err)
+	}
+
+	return nil
+}
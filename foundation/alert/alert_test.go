@@ -0,0 +1,75 @@
+package alert_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/alert"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+type sink struct {
+	mu     sync.Mutex
+	alerts []alert.Alert
+}
+
+func (s *sink) Send(ctx context.Context, alerts []alert.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alerts = append(s.alerts, alerts...)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	var s sink
+
+	d := alert.New(100)
+
+	// The records are queued before the dispatcher runs.
+	for range 5 {
+		d.Notify(t.Context(), logger.Record{Time: time.Now(), Level: logger.LevelError, Message: "db down", Caller: "db.go:10"})
+	}
+	d.Notify(t.Context(), logger.Record{Time: time.Now(), Level: logger.LevelError, Message: "db down", Caller: "db.go:20"})
+	d.Notify(t.Context(), logger.Record{Time: time.Now(), Level: logger.LevelWarn, Message: "slow", Caller: "db.go:30"})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.Run(t.Context(), alert.Config{
+			Sinks:         []alert.Sink{&s},
+			Burst:         2,
+			FlushInterval: time.Hour,
+		})
+	}()
+
+	if err := d.Shutdown(t.Context()); err != nil {
+		t.Fatalf("Should be able to shutdown: %s", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Should run without error: %s", err)
+	}
+
+	exp := []struct {
+		message string
+		caller  string
+		count   int
+	}{
+		{"db down", "db.go:10", 5},
+		{"db down", "db.go:20", 1},
+		{"alerts throttled", "alert", 1},
+	}
+
+	if len(s.alerts) != len(exp) {
+		t.Fatalf("Should send %d alerts, got %d: %+v", len(exp), len(s.alerts), s.alerts)
+	}
+
+	for i, e := range exp {
+		got := s.alerts[i]
+		if got.Message != e.message || got.Caller != e.caller || got.Count != e.count {
+			t.Errorf("Should send %s at %s %d times, got %+v", e.message, e.caller, e.count, got)
+		}
+	}
+}
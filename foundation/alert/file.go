@@ -0,0 +1,51 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// File is a sink appending the alerts to a file as JSON lines, for the
+// environments that can't reach a webhook.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile constructs a File appending to the file at the path, which is
+// created when it doesn't exist.
+func NewFile(path string) *File {
+	return &File{
+		path: path,
+	}
+}
+
+// Send appends one line per alert to the file.
+func (f *File) Send(ctx context.Context, alerts []Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// The file is opened for each batch, so it can be rotated or removed
+	// while the service runs.
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("file: open: %w", err)
+	}
+
+	enc := json.NewEncoder(file)
+	for _, a := range alerts {
+		if err := enc.Encode(a); err != nil {
+			file.Close()
+			return fmt.Errorf("file: write: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("file: close: %w", err)
+	}
+
+	return nil
+}
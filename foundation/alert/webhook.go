@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook is a sink posting the alerts as JSON to an endpoint, like
+// {"alerts":[...]}.
+type Webhook struct {
+	url  string
+	http *http.Client
+}
+
+// NewWebhook constructs a Webhook posting to the url.
+func NewWebhook(url string, options ...func(wh *Webhook)) *Webhook {
+	wh := Webhook{
+		url: url,
+		http: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	for _, option := range options {
+		option(&wh)
+	}
+
+	return &wh
+}
+
+// WithClient sets the HTTP client posting the alerts.
+func WithClient(http *http.Client) func(wh *Webhook) {
+	return func(wh *Webhook) {
+		wh.http = http
+	}
+}
+
+// Send posts the alerts to the endpoint, which must answer with a 2xx.
+func (wh *Webhook) Send(ctx context.Context, alerts []Alert) error {
+	data, err := json.Marshal(struct {
+		Alerts []Alert `json:"alerts"`
+	}{
+		Alerts: alerts,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: encode: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("webhook: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook: unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package certs provides support for serving TLS certificates that are
+// rotated on disk, like the ones mounted from a Kubernetes secret.
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval is how often the files are checked for changes.
+const checkInterval = 10 * time.Second
+
+// Reloader serves the certificate found in the cert and key files, reloading
+// it when the files change. The files are checked during the handshakes, at
+// most once per interval, so no goroutine is required.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// WithInterval sets how often the files are checked for changes.
+func WithInterval(interval time.Duration) func(r *Reloader) {
+	return func(r *Reloader) {
+		r.interval = interval
+	}
+}
+
+// NewReloader constructs a Reloader, loading the certificate right away so
+// a bad certificate is reported at startup.
+func NewReloader(certFile string, keyFile string, options ...func(r *Reloader)) (*Reloader, error) {
+	r := Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: checkInterval,
+	}
+
+	for _, option := range options {
+		option(&r)
+	}
+
+	if err := r.load(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetCertificate returns the current certificate. It's meant to be used as
+// the GetCertificate function of a tls.Config. When reloading fails, the
+// previous certificate is kept, so a partially written file doesn't take the
+// server down.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	now := time.Now()
+
+	r.mu.RLock()
+	cert := r.cert
+	stale := now.Sub(r.lastCheck) >= r.interval
+	r.mu.RUnlock()
+
+	if stale {
+		if err := r.load(now); err == nil {
+			r.mu.RLock()
+			cert = r.cert
+			r.mu.RUnlock()
+		}
+	}
+
+	return cert, nil
+}
+
+// load reads the files when they changed since the last load.
+func (r *Reloader) load(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastCheck = now
+
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading key pair: %w", err)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+
+	return nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("stat: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// ParseVersion returns the TLS version for its name, like "1.2" or "1.3".
+func ParseVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("unknown tls version %q", version)
+}
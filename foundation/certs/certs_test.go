@@ -0,0 +1,123 @@
+package certs_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/certs"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeCert(t, certFile, keyFile, 1, time.Now())
+
+	r, err := certs.NewReloader(certFile, keyFile, certs.WithInterval(0))
+	if err != nil {
+		t.Fatalf("Should be able to load the certificate: %s", err)
+	}
+
+	if serial := serialOf(t, r); serial != 1 {
+		t.Fatalf("Should serve the certificate, got serial %d", serial)
+	}
+
+	t.Run("reload", func(t *testing.T) {
+		writeCert(t, certFile, keyFile, 2, time.Now().Add(time.Minute))
+
+		if serial := serialOf(t, r); serial != 2 {
+			t.Fatalf("Should serve the rotated certificate, got serial %d", serial)
+		}
+	})
+
+	t.Run("bad-cert", func(t *testing.T) {
+		if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+			t.Fatalf("Should be able to write the file: %s", err)
+		}
+		touch(t, time.Now().Add(2*time.Minute), certFile)
+
+		if serial := serialOf(t, r); serial != 2 {
+			t.Fatalf("Should keep serving the previous certificate, got serial %d", serial)
+		}
+	})
+
+	t.Run("startup", func(t *testing.T) {
+		if _, err := certs.NewReloader(certFile, keyFile); err == nil {
+			t.Fatal("Should fail to start with a bad certificate.")
+		}
+	})
+}
+
+func serialOf(t *testing.T, r *certs.Reloader) int64 {
+	t.Helper()
+
+	cert, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("Should be able to get the certificate: %s", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Should be able to parse the certificate: %s", err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
+
+// writeCert writes a self-signed certificate with the serial number and sets
+// the modification time of the files.
+func writeCert(t *testing.T, certFile string, keyFile string, serial int64, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Should be able to create a certificate: %s", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the key: %s", err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Should be able to write the certificate: %s", err)
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Should be able to write the key: %s", err)
+	}
+
+	touch(t, modTime, certFile, keyFile)
+}
+
+func touch(t *testing.T, modTime time.Time, files ...string) {
+	t.Helper()
+
+	for _, file := range files {
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatalf("Should be able to set the modification time: %s", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package health provides a registry of checks used to report if a service
+// is ready to receive traffic.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Set of statuses reported by the checks.
+const (
+	StatusOK           = "ok"
+	StatusDegraded     = "degraded"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// defaultTimeout is used by the checks that don't set a timeout.
+const defaultTimeout = time.Second
+
+// Check represents a named check of a subsystem. A failing critical check
+// makes the service not ready, while a failing non-critical check only
+// degrades it.
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	Critical bool
+	Fn       func(ctx context.Context) error
+}
+
+// Result represents the outcome of a check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report represents the outcome of every check.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Ready reports if the service can receive traffic.
+func (r Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+// Registry holds the checks of the service.
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+// New constructs an empty Registry.
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register adds the check to the registry.
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check)
+}
+
+// Shutdown makes every report fail from now on, so the load balancer stops
+// sending traffic while the requests in flight are drained.
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// Check runs the checks concurrently, each one with its own timeout. A nil
+// registry has no checks and is always ready.
+func (r *Registry) Check(ctx context.Context) Report {
+	if r == nil {
+		return Report{Status: StatusOK, Checks: []Result{}}
+	}
+
+	if r.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, Checks: []Result{}}
+	}
+
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	status := StatusOK
+	for _, res := range results {
+		if res.Status == StatusOK {
+			continue
+		}
+
+		if res.Critical {
+			status = StatusFail
+			break
+		}
+
+		status = StatusDegraded
+	}
+
+	return Report{
+		Status: status,
+		Checks: results,
+	}
+}
+
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// The check runs in its own goroutine, so a check ignoring the context
+	// can't block the report past its timeout.
+	ch := make(chan error, 1)
+	go func() {
+		ch <- check.Fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Name:     check.Name,
+		Status:   StatusOK,
+		Critical: check.Critical,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+
+	return res
+}
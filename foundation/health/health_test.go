@@ -0,0 +1,144 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/health"
+)
+
+func TestCheck(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("down") }
+
+	tests := []struct {
+		name   string
+		checks []health.Check
+		status string
+		ready  bool
+	}{
+		{
+			name:   "none",
+			status: health.StatusOK,
+			ready:  true,
+		},
+		{
+			name: "ok",
+			checks: []health.Check{
+				{Name: "db", Critical: true, Fn: ok},
+				{Name: "cache", Fn: ok},
+			},
+			status: health.StatusOK,
+			ready:  true,
+		},
+		{
+			name: "non-critical",
+			checks: []health.Check{
+				{Name: "db", Critical: true, Fn: ok},
+				{Name: "cache", Fn: fail},
+			},
+			status: health.StatusDegraded,
+			ready:  true,
+		},
+		{
+			name: "critical",
+			checks: []health.Check{
+				{Name: "cache", Fn: fail},
+				{Name: "db", Critical: true, Fn: fail},
+			},
+			status: health.StatusFail,
+			ready:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := health.New()
+			for _, check := range tt.checks {
+				r.Register(check)
+			}
+
+			report := r.Check(context.Background())
+
+			if report.Status != tt.status {
+				t.Fatalf("Should report the status %q, got %q", tt.status, report.Status)
+			}
+
+			if report.Ready() != tt.ready {
+				t.Fatalf("Should report ready %t, got %t", tt.ready, report.Ready())
+			}
+
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("Should report every check, got %d", len(report.Checks))
+			}
+
+			for i, res := range report.Checks {
+				if res.Name != tt.checks[i].Name {
+					t.Fatalf("Should report the checks in order, got %q for %q", res.Name, tt.checks[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	r := health.New()
+	r.Register(health.Check{
+		Name:     "stuck",
+		Timeout:  10 * time.Millisecond,
+		Critical: true,
+		Fn: func(ctx context.Context) error {
+			<-block
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := r.Check(context.Background())
+
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Should not wait for a check past its timeout, took %s", d)
+	}
+
+	if report.Status != health.StatusFail {
+		t.Fatalf("Should fail a check that times out, got %q", report.Status)
+	}
+
+	if !strings.Contains(report.Checks[0].Error, context.DeadlineExceeded.Error()) {
+		t.Fatalf("Should report the timeout, got %q", report.Checks[0].Error)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	r := health.New()
+	r.Register(health.Check{
+		Name: "db",
+		Fn:   func(ctx context.Context) error { return nil },
+	})
+
+	r.Shutdown()
+
+	report := r.Check(context.Background())
+
+	if report.Status != health.StatusShuttingDown {
+		t.Fatalf("Should report shutting down, got %q", report.Status)
+	}
+
+	if report.Ready() {
+		t.Fatal("Should not be ready while shutting down.")
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *health.Registry
+
+	if report := r.Check(context.Background()); !report.Ready() {
+		t.Fatalf("Should be ready without checks, got %q", report.Status)
+	}
+}
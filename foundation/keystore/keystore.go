@@ -120,6 +120,22 @@ func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
 	return nil
 }
 
+// AddPrivateKey adds the RSA private PEM to the store using the key
+// identifier. It's useful when the keys don't live on disk, like in tests.
+func (ks *KeyStore) AddPrivateKey(kid string, privatePEM string) error {
+	publicPEM, err := toPublicPEM(privatePEM)
+	if err != nil {
+		return fmt.Errorf("unable to convert to public PEM: %w", err)
+	}
+
+	ks.store[kid] = Key{
+		privatePEM: privatePEM,
+		publicPEM:  publicPEM,
+	}
+
+	return nil
+}
+
 func toPublicPEM(privatePEM string) (string, error) {
 	block, _ := pem.Decode([]byte(privatePEM))
 	if block == nil {
@@ -0,0 +1,138 @@
+// Package lifecycle provides support to run the components of a service,
+// like its servers and background workers, and to shut them down together.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+// Component represents a part of the service with its own lifecycle. Start
+// must block until the component stops, and return nil when it was stopped
+// by Stop. A component without Start only takes part in the shutdown, like
+// a step that must happen before the servers stop.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Server returns a component running the http server. The serve function is
+// usually the ListenAndServe method of the server.
+func Server(name string, srv *http.Server, serve func() error) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// Do not accept any more traffic and wait for the requests to
+			// finish. The requests still running when the context is done
+			// are cut off by closing the server.
+			if err := srv.Shutdown(ctx); err != nil {
+				srv.Close()
+				return fmt.Errorf("could not stop server gracefully: %w", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+// Manager runs the components of a service.
+type Manager struct {
+	log             *logger.Logger
+	shutdownTimeout time.Duration
+	components      []Component
+}
+
+// New constructs a Manager that gives the components the timeout to stop.
+func New(log *logger.Logger, shutdownTimeout time.Duration) *Manager {
+	return &Manager{
+		log:             log,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// Add registers the component. The components are stopped in the order they
+// were added.
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// Run starts the components and blocks until a signal is received on the
+// shutdown channel or a component fails. Then every component is stopped in
+// order, sharing the shutdown timeout. The first failure of a component is
+// returned along with the errors of stopping the components.
+func (m *Manager) Run(ctx context.Context, shutdown <-chan os.Signal) error {
+	g, gctx := errgroup.WithContext(ctx)
+
+	for _, c := range m.components {
+		if c.Start == nil {
+			continue
+		}
+
+		g.Go(func() error {
+			m.log.Info(ctx, "startup", "status", "component started", "component", c.Name)
+
+			if err := c.Start(gctx); err != nil {
+				return fmt.Errorf("%s: %w", c.Name, err)
+			}
+
+			return nil
+		})
+	}
+
+	var stopErr error
+
+	g.Go(func() error {
+		select {
+		case sig := <-shutdown:
+			m.log.Info(ctx, "shutdown", "status", "shutdown started", "signal", sig)
+			defer m.log.Info(ctx, "shutdown", "status", "shutdown completed", "signal", sig)
+
+		case <-gctx.Done():
+			m.log.Info(ctx, "shutdown", "status", "shutdown started", "reason", "component failed")
+		}
+
+		stopErr = m.stop(context.WithoutCancel(ctx))
+
+		return nil
+	})
+
+	return errors.Join(g.Wait(), stopErr)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	// How long are we going to wait for the components to finish their
+	// work? We are executing this in Kubernetes, so the timeout must be
+	// shorter than the termination grace period of the pod.
+	ctx, cancel := context.WithTimeout(ctx, m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, c := range m.components {
+		if c.Stop == nil {
+			continue
+		}
+
+		m.log.Info(ctx, "shutdown", "status", "stopping component", "component", c.Name)
+
+		if err := c.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/lifecycle"
+	"github.com/zucchini/services-golang/foundation/logger"
+)
+
+func TestRunShutdown(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	m := lifecycle.New(log, time.Second)
+
+	var mu sync.Mutex
+	var stopped []string
+
+	for _, name := range []string{"drain", "api", "debug"} {
+		done := make(chan struct{})
+
+		c := lifecycle.Component{
+			Name: name,
+			Stop: func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); !ok {
+					t.Errorf("Should stop %s with the shutdown timeout.", name)
+				}
+
+				mu.Lock()
+				stopped = append(stopped, name)
+				mu.Unlock()
+
+				close(done)
+				return nil
+			},
+		}
+
+		// The drain step only takes part in the shutdown.
+		if name != "drain" {
+			c.Start = func(ctx context.Context) error {
+				<-done
+				return nil
+			}
+		}
+
+		m.Add(c)
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	shutdown <- syscall.SIGTERM
+
+	if err := m.Run(context.Background(), shutdown); err != nil {
+		t.Fatalf("Should shut down without errors: %s", err)
+	}
+
+	if want := []string{"drain", "api", "debug"}; !slices.Equal(stopped, want) {
+		t.Fatalf("Should stop the components in order, got %v", stopped)
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	log := logger.New(io.Discard, logger.LevelInfo, "TEST", nil)
+	m := lifecycle.New(log, time.Second)
+
+	errStart := errors.New("listen failed")
+	errStop := errors.New("stop failed")
+
+	m.Add(lifecycle.Component{
+		Name: "api",
+		Start: func(ctx context.Context) error {
+			return errStart
+		},
+		Stop: func(ctx context.Context) error {
+			return errStop
+		},
+	})
+
+	done := make(chan struct{})
+	var stopped bool
+
+	m.Add(lifecycle.Component{
+		Name: "worker",
+		Start: func(ctx context.Context) error {
+			<-done
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopped = true
+			close(done)
+			return nil
+		},
+	})
+
+	err := m.Run(context.Background(), make(chan os.Signal))
+
+	if !errors.Is(err, errStart) {
+		t.Fatalf("Should return the failure of the component: %v", err)
+	}
+
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Should return the errors of stopping the components: %v", err)
+	}
+
+	if !stopped {
+		t.Fatal("Should stop the other components when one fails.")
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type ctxKey int
+
+const attrsKey ctxKey = 1
+
+// AddAttrs returns a copy of the context carrying the attributes, which are
+// added to every record logged with it. An attribute replaces the one with
+// the same key already carried.
+func AddAttrs(ctx context.Context, args ...any) context.Context {
+	current := Attrs(ctx)
+
+	attrs := make([]slog.Attr, len(current), len(current)+len(args))
+	copy(attrs, current)
+
+next:
+	for _, a := range toAttrs(args) {
+		for i := range attrs {
+			if attrs[i].Key == a.Key {
+				attrs[i] = a
+				continue next
+			}
+		}
+
+		attrs = append(attrs, a)
+	}
+
+	return context.WithValue(ctx, attrsKey, attrs)
+}
+
+// Attrs returns the attributes carried by the context.
+func Attrs(ctx context.Context) []slog.Attr {
+	v, ok := ctx.Value(attrsKey).([]slog.Attr)
+	if !ok {
+		return nil
+	}
+
+	return v
+}
+
+// toAttrs converts the alternating keys and values, or attributes, into
+// attributes the same way slog does.
+func toAttrs(args []any) []slog.Attr {
+	return slog.Group("", args...).Value.Group()
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, LevelInfo, "TEST", nil)
+
+	ctx := AddAttrs(context.Background(), "route", "GET /v1/users", "user_id", "1")
+	ctx = AddAttrs(ctx, "user_id", "2")
+
+	log.With("component", "users").WithGroup("req").Info(ctx, "started", "method", "GET")
+
+	var got struct {
+		Component string         `json:"component"`
+		Req       map[string]any `json:"req"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Should be able to decode the log: %s: %s", err, buf.String())
+	}
+
+	if got.Component != "users" {
+		t.Errorf("Should log the attributes of the logger, got %q", got.Component)
+	}
+
+	exp := map[string]any{"method": "GET", "route": "GET /v1/users", "user_id": "2"}
+	for k, v := range exp {
+		if got.Req[k] != v {
+			t.Errorf("Should log %s=%v in the group, got %v", k, v, got.Req[k])
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ParseLevel parses the name of a level, like DEBUG or info.
+func ParseLevel(s string) (Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("parse level: %w", err)
+	}
+
+	return Level(level), nil
+}
+
+// ParseSourceLevel parses the level of a source in the source=LEVEL form,
+// like sqldb=DEBUG.
+func ParseSourceLevel(s string) (string, Level, error) {
+	source, name, ok := strings.Cut(s, "=")
+	if !ok || source == "" {
+		return "", 0, fmt.Errorf("parse source level: %q must be in the source=LEVEL form", s)
+	}
+
+	level, err := ParseLevel(name)
+	if err != nil {
+		return "", 0, fmt.Errorf("parse source level: %s: %w", source, err)
+	}
+
+	return source, level, nil
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	return slog.Level(l).String()
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (l Level) MarshalText() ([]byte, error) {
+	return slog.Level(l).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (l *Level) UnmarshalText(data []byte) error {
+	level, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+
+	*l = level
+	return nil
+}
+
+// =============================================================================
+
+// LevelSettings represents the levels a LevelVar is set to.
+type LevelSettings struct {
+	Level   Level            `json:"level"`
+	Sources map[string]Level `json:"sources"`
+}
+
+// LevelVar holds the minimum level to log, which can be changed while the
+// service is running. The level can be overridden for a source, which is the
+// source of the logger, like sqldb, or else the name of the package doing
+// the logging.
+type LevelVar struct {
+	mu      sync.Mutex
+	level   atomic.Int64
+	min     atomic.Int64
+	sources atomic.Pointer[map[string]Level]
+}
+
+// NewLevelVar constructs a LevelVar set to the level.
+func NewLevelVar(level Level) *LevelVar {
+	var v LevelVar
+	v.level.Store(int64(level))
+	v.min.Store(int64(level))
+	v.sources.Store(&map[string]Level{})
+
+	return &v
+}
+
+// Level returns the level used by the sources that aren't overridden.
+func (v *LevelVar) Level() Level {
+	return Level(v.level.Load())
+}
+
+// Set changes the level used by the sources that aren't overridden.
+func (v *LevelVar) Set(level Level) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.level.Store(int64(level))
+	v.updateMin()
+}
+
+// SetSource overrides the level of the source.
+func (v *LevelVar) SetSource(source string, level Level) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	sources := maps.Clone(*v.sources.Load())
+	sources[source] = level
+	v.sources.Store(&sources)
+
+	v.updateMin()
+}
+
+// ResetSource removes the override of the source, which uses the level
+// again.
+func (v *LevelVar) ResetSource(source string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	sources := maps.Clone(*v.sources.Load())
+	delete(sources, source)
+	v.sources.Store(&sources)
+
+	v.updateMin()
+}
+
+// Settings returns the level and the overrides of the sources.
+func (v *LevelVar) Settings() LevelSettings {
+	return LevelSettings{
+		Level:   v.Level(),
+		Sources: maps.Clone(*v.sources.Load()),
+	}
+}
+
+// updateMin stores the lowest of the levels, which is what the handler must
+// let through. It must be called with the mutex held.
+func (v *LevelVar) updateMin() {
+	level := v.Level()
+	for _, l := range *v.sources.Load() {
+		level = min(level, l)
+	}
+
+	v.min.Store(int64(level))
+}
+
+// enabled reports if the level is logged for the source, or for the package
+// of the pc when the source is empty.
+func (v *LevelVar) enabled(level Level, src string, pc uintptr) bool {
+	if level < Level(v.min.Load()) {
+		return false
+	}
+
+	sources := *v.sources.Load()
+	if len(sources) == 0 {
+		return true
+	}
+
+	if src == "" {
+		src = source(pc)
+	}
+
+	if l, exists := sources[src]; exists {
+		return level >= l
+	}
+
+	return level >= v.Level()
+}
+
+// leveler returns the lowest of the levels for the slog handler, so the
+// records enabled by an override are not dropped.
+func (v *LevelVar) leveler() slog.Leveler {
+	return minLeveler{v: v}
+}
+
+type minLeveler struct {
+	v *LevelVar
+}
+
+func (l minLeveler) Level() slog.Level {
+	return slog.Level(l.v.min.Load())
+}
+
+// =============================================================================
+
+// sources caches the name of the package of a pc, since logging the same
+// lines over and over is the common case.
+var sources sync.Map
+
+// source returns the name of the package of the function holding the pc.
+func source(pc uintptr) string {
+	if s, exists := sources.Load(pc); exists {
+		return s.(string)
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	// The function is in the import/path/pkg.Func form.
+	name := frame.Function
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+
+	sources.Store(pc, name)
+
+	return name
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSourceLevels(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, LevelInfo, "TEST", nil)
+
+	ctx := context.Background()
+
+	logged := func(msg string) bool {
+		return strings.Contains(buf.String(), `"msg":"`+msg+`"`)
+	}
+
+	log.Debug(ctx, "before")
+	if logged("before") {
+		t.Fatal("Should not log debug at the info level.")
+	}
+
+	log.SetSourceLevel(ctx, "sqldb", LevelDebug)
+	log.Debug(ctx, "other")
+	if logged("other") {
+		t.Fatal("Should not log debug for a source that isn't overridden.")
+	}
+
+	// The test runs in the logger package, so it's the source of the logs.
+	log.SetSourceLevel(ctx, "logger", LevelDebug)
+	log.Debug(ctx, "overridden")
+	if !logged("overridden") {
+		t.Fatal("Should log debug for an overridden source.")
+	}
+
+	if !logged("log level changed") {
+		t.Fatal("Should log the level change.")
+	}
+
+	log.ResetSourceLevel(ctx, "logger")
+	log.Debug(ctx, "reset")
+	if logged("reset") {
+		t.Fatal("Should not log debug once the source is reset.")
+	}
+
+	log.SetLevel(ctx, LevelError)
+	log.Warn(ctx, "raised")
+	if logged("raised") {
+		t.Fatal("Should not log warn at the error level.")
+	}
+
+	settings := log.Levels()
+	if settings.Level != LevelError || settings.Sources["sqldb"] != LevelDebug {
+		t.Fatalf("Should report the levels, got %+v", settings)
+	}
+}
+
+func TestLoggerSource(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, LevelWarn, "TEST", nil)
+
+	ctx := context.Background()
+
+	// Like sqldb, which logs on behalf of its caller, so the package of the
+	// caller isn't the source of the record.
+	query := func(log *Logger) {
+		func() {
+			log.Infoc(ctx, 4, "query")
+		}()
+	}
+
+	log.SetSourceLevel(ctx, "sqldb", LevelDebug)
+
+	buf.Reset()
+	query(log)
+	if buf.Len() != 0 {
+		t.Fatalf("Should not log info for the package of the caller: %s", buf.String())
+	}
+
+	query(log.Source("sqldb"))
+	if !strings.Contains(buf.String(), `"msg":"query"`) || !strings.Contains(buf.String(), `"source":"sqldb"`) {
+		t.Fatalf("Should log info at the level of the source of the logger: %s", buf.String())
+	}
+
+	log.ResetSourceLevel(ctx, "sqldb")
+
+	buf.Reset()
+	query(log.Source("sqldb"))
+	if strings.Contains(buf.String(), `"msg":"query"`) {
+		t.Fatalf("Should not log info once the source is reset: %s", buf.String())
+	}
+}
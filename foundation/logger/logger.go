@@ -21,16 +21,46 @@ type TraceIDFn func(ctx context.Context) string
 type Logger struct {
 	handler   slog.Handler
 	traceIDFn TraceIDFn
+	levels    *LevelVar
+	source    string
 }
 
-// New constructs a new log for application use.
-func New(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn) *Logger {
-	return new(w, minLevel, serviceName, traceIDFn, Events{})
+// Options represents the optional settings of a logger.
+type Options struct {
+	redactor *Redactor
+}
+
+// WithRedactor replaces the default redaction of the records. A nil Redactor
+// turns the redaction off.
+func WithRedactor(r *Redactor) func(o *Options) {
+	return func(o *Options) {
+		o.redactor = r
+	}
+}
+
+// New constructs a new log for application use. The sensitive data of the
+// records is redacted by default.
+func New(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, options ...func(o *Options)) *Logger {
+	return new(writerSinks(w), minLevel, serviceName, traceIDFn, Events{}, options...)
 }
 
 // NewWithEvents constructs a new log for application use with events.
-func NewWithEvents(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, events Events) *Logger {
-	return new(w, minLevel, serviceName, traceIDFn, events)
+func NewWithEvents(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, events Events, options ...func(o *Options)) *Logger {
+	return new(writerSinks(w), minLevel, serviceName, traceIDFn, events, options...)
+}
+
+// NewWithSinks constructs a new log for application use writing every record
+// to each of the sinks whose minimum level allows it, like JSON to stdout
+// and only the errors to a file.
+func NewWithSinks(sinks []Sink, minLevel Level, serviceName string, traceIDFn TraceIDFn, events Events, options ...func(o *Options)) *Logger {
+	return new(sinks, minLevel, serviceName, traceIDFn, events, options...)
+}
+
+// writerSinks returns the sink of the constructors taking a writer.
+func writerSinks(w io.Writer) []Sink {
+	return []Sink{
+		{Writer: w, Format: FormatJSON, MinLevel: noMinLevel},
+	}
 }
 
 // NewWithHandler returns a new log for application use with the underlying
@@ -44,6 +74,43 @@ func NewStdLogger(logger *Logger, level Level) *log.Logger {
 	return slog.NewLogLogger(logger.handler, slog.Level(level))
 }
 
+// With returns a logger that adds the attributes to every record.
+func (log *Logger) With(args ...any) *Logger {
+	if len(args) == 0 {
+		return log
+	}
+
+	l := *log
+	l.handler = log.handler.WithAttrs(toAttrs(args))
+
+	return &l
+}
+
+// WithGroup returns a logger that qualifies the keys of the attributes of
+// every record with the group name.
+func (log *Logger) WithGroup(name string) *Logger {
+	if name == "" {
+		return log
+	}
+
+	l := *log
+	l.handler = log.handler.WithGroup(name)
+
+	return &l
+}
+
+// Source returns a logger whose records belong to the named source, like
+// sqldb, and are logged at the level of that source. It's meant for the
+// packages logging on behalf of their callers, since the source is otherwise
+// the package of the function doing the logging. The records carry the
+// source attribute.
+func (log *Logger) Source(name string) *Logger {
+	l := log.With("source", name)
+	l.source = name
+
+	return l
+}
+
 // Debug logs at LevelDebug with the given context.
 func (log *Logger) Debug(ctx context.Context, msg string, args ...any) {
 	log.write(ctx, LevelDebug, 3, msg, args...)
@@ -84,27 +151,110 @@ func (log *Logger) Errorc(ctx context.Context, caller int, msg string, args ...a
 	log.write(ctx, LevelError, caller, msg, args...)
 }
 
-func (log *Logger) write(ctx context.Context, level Level, caller int, msg string, args ...any) {
-	slogLevel := slog.Level(level)
+// Levels returns the levels the logger is set to.
+func (log *Logger) Levels() LevelSettings {
+	if log.levels == nil {
+		return LevelSettings{Sources: map[string]Level{}}
+	}
+
+	return log.levels.Settings()
+}
+
+// SetLevel changes the minimum level to log. The change is logged.
+func (log *Logger) SetLevel(ctx context.Context, level Level) {
+	if log.levels == nil {
+		return
+	}
+
+	old := log.levels.Level()
+	if old == level {
+		return
+	}
+
+	log.change(ctx, func() { log.levels.Set(level) }, "from", old, "to", level)
+}
+
+// SetSourceLevel overrides the minimum level to log for the source, which is
+// the source of the logger or else the name of the package doing the
+// logging. The change is logged.
+func (log *Logger) SetSourceLevel(ctx context.Context, source string, level Level) {
+	if log.levels == nil {
+		return
+	}
 
-	if !log.handler.Enabled(ctx, slogLevel) {
+	old, exists := log.levels.Settings().Sources[source]
+	if exists && old == level {
+		return
+	}
+
+	if !exists {
+		old = log.levels.Level()
+	}
+
+	log.change(ctx, func() { log.levels.SetSource(source, level) }, "source", source, "from", old, "to", level)
+}
+
+// ResetSourceLevel removes the override of the source, which logs at the
+// minimum level again. The change is logged.
+func (log *Logger) ResetSourceLevel(ctx context.Context, source string) {
+	if log.levels == nil {
+		return
+	}
+
+	old, exists := log.levels.Settings().Sources[source]
+	if !exists {
+		return
+	}
+
+	log.change(ctx, func() { log.levels.ResetSource(source) }, "source", source, "from", old, "to", log.levels.Level())
+}
+
+// change applies a level change and logs it. The change is always logged,
+// whatever the levels are, so it can't be lost when a level is raised.
+func (log *Logger) change(ctx context.Context, apply func(), args ...any) {
+	apply()
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	log.emit(ctx, LevelInfo, pcs[0], "log level changed", args...)
+}
+
+func (log *Logger) write(ctx context.Context, level Level, caller int, msg string, args ...any) {
+	if !log.handler.Enabled(ctx, slog.Level(level)) {
 		return
 	}
 
 	var pcs [1]uintptr
 	runtime.Callers(caller, pcs[:])
 
-	r := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	if log.levels != nil && !log.levels.enabled(level, log.source, pcs[0]) {
+		return
+	}
+
+	log.emit(ctx, level, pcs[0], msg, args...)
+}
+
+func (log *Logger) emit(ctx context.Context, level Level, pc uintptr, msg string, args ...any) {
+	r := slog.NewRecord(time.Now(), slog.Level(level), msg, pc)
+	r.Add(args...)
+	r.AddAttrs(Attrs(ctx)...)
 
 	if log.traceIDFn != nil {
-		args = append(args, "trace_id", log.traceIDFn(ctx))
+		r.Add("trace_id", log.traceIDFn(ctx))
 	}
-	r.Add(args...)
 
 	log.handler.Handle(ctx, r)
 }
 
-func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, events Events) *Logger {
+func new(sinks []Sink, minLevel Level, serviceName string, traceIDFn TraceIDFn, events Events, options ...func(o *Options)) *Logger {
+	opts := Options{
+		redactor: NewRedactor(),
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
 
 	// Convert the file name to just the name.ext when this key/value will
 	// be logged.
@@ -119,15 +269,38 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 		return a
 	}
 
-	// Construct the slog JSON handler for use.
-	handler := slog.Handler(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: slog.Level(minLevel), ReplaceAttr: f}))
+	// The level can be changed while the service is running, so the handler
+	// reads it from the LevelVar.
+	levels := NewLevelVar(minLevel)
+
+	// Construct the slog handler of each sink, all of them sharing the
+	// service attribute and the file name conversion.
+	handlers := make([]slog.Handler, len(sinks))
+	for i, sink := range sinks {
+		handlers[i] = newSinkHandler(sink, levels, f)
+	}
 
-	// If events are to be processed, wrap the JSON handler around the custom
-	// log handler.
+	handler := slog.Handler(slog.DiscardHandler)
+	switch len(handlers) {
+	case 0:
+	case 1:
+		handler = handlers[0]
+	default:
+		handler = newMultiHandler(handlers)
+	}
+
+	// If events are to be processed, wrap the handler around the custom log
+	// handler.
 	if events.Debug != nil || events.Info != nil || events.Warn != nil || events.Error != nil {
 		handler = newLogHandler(handler, events)
 	}
 
+	// Mask the sensitive data before the records reach the events or are
+	// written.
+	if opts.redactor != nil {
+		handler = newRedactHandler(handler, opts.redactor)
+	}
+
 	// Attributes to add to every log.
 	attrs := []slog.Attr{
 		{Key: "service", Value: slog.StringValue(serviceName)},
@@ -139,5 +312,6 @@ func new(w io.Writer, minLevel Level, serviceName string, traceIDFn TraceIDFn, e
 	return &Logger{
 		handler:   handler,
 		traceIDFn: traceIDFn,
+		levels:    levels,
 	}
 }
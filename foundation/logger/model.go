@@ -2,6 +2,9 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
 	"time"
 
 	"log/slog"
@@ -23,6 +26,7 @@ type Record struct {
 	Time       time.Time
 	Message    string
 	Level      Level
+	Caller     string
 	Attributes map[string]any
 }
 
@@ -39,10 +43,22 @@ func toRecord(r slog.Record) Record {
 		Time:       r.Time,
 		Message:    r.Message,
 		Level:      Level(r.Level),
+		Caller:     caller(r.PC),
 		Attributes: atts,
 	}
 }
 
+// caller returns the file:line of the pc, like the file key of the logs.
+func caller(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+
+	return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+}
+
 // EventFn is a function to be executed when configured against a log level.
 type EventFn func(ctx context.Context, r Record)
 
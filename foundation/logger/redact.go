@@ -0,0 +1,267 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the sensitive data in the records.
+const Redacted = "[REDACTED]"
+
+// DefaultKeys are the patterns of the keys whose values are always redacted.
+var DefaultKeys = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)passw(or)?d|secret|token|authorization|cookie|credential|api[-_]?key|private[-_]?key`),
+}
+
+// DefaultDetectors find the credentials commonly seen in values.
+var DefaultDetectors = []Detector{
+	{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	},
+	{
+		Name:    "pem",
+		Pattern: regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]+-----.*?(-----END [A-Z0-9 ]+-----|$)`),
+	},
+	{
+		Name:    "basic",
+		Pattern: regexp.MustCompile(`(?i)\bbasic\s+[A-Za-z0-9+/]+={0,2}`),
+		Match:   isBasicCredentials,
+	},
+}
+
+// Detector finds sensitive data in the string values, whatever their key.
+// Match confirms a match of the pattern is sensitive, for the patterns that
+// can match data that isn't.
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Match   func(s string) bool
+}
+
+// Redactor masks the sensitive data of the records before they are written.
+// The values of structs, maps and slices are walked through their JSON form,
+// so their members are masked by their JSON name and their string values are
+// checked by the detectors.
+type Redactor struct {
+	keys      []*regexp.Regexp
+	detectors []Detector
+}
+
+// NewRedactor constructs a Redactor using the default keys and detectors.
+func NewRedactor(options ...func(r *Redactor)) *Redactor {
+	r := Redactor{
+		keys:      DefaultKeys,
+		detectors: DefaultDetectors,
+	}
+
+	for _, option := range options {
+		option(&r)
+	}
+
+	return &r
+}
+
+// WithKeys adds patterns of the keys whose values are redacted.
+func WithKeys(keys ...*regexp.Regexp) func(r *Redactor) {
+	return func(r *Redactor) {
+		r.keys = append(r.keys[:len(r.keys):len(r.keys)], keys...)
+	}
+}
+
+// WithDetectors adds detectors of sensitive data in the values.
+func WithDetectors(detectors ...Detector) func(r *Redactor) {
+	return func(r *Redactor) {
+		r.detectors = append(r.detectors[:len(r.detectors):len(r.detectors)], detectors...)
+	}
+}
+
+// Attr returns the attribute with its sensitive data masked.
+func (r *Redactor) Attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if r.sensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(r.attrs(a.Value.Group())...)}
+
+	case slog.KindString:
+		if s, changed := r.String(a.Value.String()); changed {
+			return slog.String(a.Key, s)
+		}
+
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			if s, changed := r.String(err.Error()); changed {
+				return slog.String(a.Key, s)
+			}
+			return a
+		}
+
+		if v, changed := r.value(a.Value.Any()); changed {
+			return slog.Any(a.Key, v)
+		}
+	}
+
+	return a
+}
+
+func (r *Redactor) sensitiveKey(key string) bool {
+	for _, k := range r.keys {
+		if k.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// value returns the JSON form of the value with its sensitive data masked,
+// and if anything was masked. The value is kept as is otherwise.
+func (r *Redactor) value(v any) (any, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v, false
+	}
+
+	// The numbers are decoded as they are written, so large integers keep
+	// their precision.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return v, false
+	}
+
+	return r.walk(doc)
+}
+
+// walk masks the members of the JSON document with a sensitive key, and the
+// data found by the detectors in its strings.
+func (r *Redactor) walk(doc any) (any, bool) {
+	changed := false
+
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, member := range v {
+			if r.sensitiveKey(key) {
+				v[key], changed = Redacted, true
+				continue
+			}
+
+			if m, c := r.walk(member); c {
+				v[key], changed = m, true
+			}
+		}
+
+	case []any:
+		for i, elem := range v {
+			if e, c := r.walk(elem); c {
+				v[i], changed = e, true
+			}
+		}
+
+	case string:
+		return r.String(v)
+	}
+
+	return doc, changed
+}
+
+func (r *Redactor) attrs(attrs []slog.Attr) []slog.Attr {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = r.Attr(a)
+	}
+
+	return redacted
+}
+
+// String returns the string with the data found by the detectors masked, and
+// if anything was masked.
+func (r *Redactor) String(s string) (string, bool) {
+	changed := false
+
+	for _, d := range r.detectors {
+		s = d.Pattern.ReplaceAllStringFunc(s, func(m string) string {
+			if d.Match != nil && !d.Match(m) {
+				return m
+			}
+
+			changed = true
+			return Redacted
+		})
+	}
+
+	return s, changed
+}
+
+// isBasicCredentials reports if the match of Basic followed by a word is
+// the base64 encoding of a user:password pair.
+func isBasicCredentials(m string) bool {
+	fields := strings.Fields(m)
+	if len(fields) != 2 {
+		return false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(data), ":")
+}
+
+// =============================================================================
+
+// redactHandler masks the sensitive data of the records before they reach
+// the handler.
+type redactHandler struct {
+	handler  slog.Handler
+	redactor *Redactor
+}
+
+func newRedactHandler(handler slog.Handler, redactor *Redactor) *redactHandler {
+	return &redactHandler{
+		handler:  handler,
+		redactor: redactor,
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// WithAttrs returns a new handler whose attributes are masked.
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &redactHandler{handler: h.handler.WithAttrs(h.redactor.attrs(attrs)), redactor: h.redactor}
+}
+
+// WithGroup returns a new handler with the given group appended to the
+// receiver's existing groups.
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{handler: h.handler.WithGroup(name), redactor: h.redactor}
+}
+
+// Handle masks the message and the attributes of the record.
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	msg, _ := h.redactor.String(r.Message)
+
+	redacted := slog.NewRecord(r.Time, r.Level, msg, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactor.Attr(a))
+		return true
+	})
+
+	return h.handler.Handle(ctx, redacted)
+}
@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+)
+
+// Format represents how the records are written to a sink.
+type Format string
+
+// Set of formats the records can be written in.
+const (
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+	FormatText   Format = "text"
+)
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSON, FormatLogfmt, FormatText:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("parse format: unknown format %q", s)
+}
+
+// Sink represents an output of the logger. A sink only writes the records at
+// or above its minimum level, on top of the levels of the logger. The zero
+// Format is JSON and the zero MinLevel is Info.
+type Sink struct {
+	Writer   io.Writer
+	Format   Format
+	MinLevel Level
+}
+
+// noMinLevel is the minimum level of the sink of the constructors taking a
+// writer, which only follows the levels of the logger.
+const noMinLevel = Level(math.MinInt)
+
+// sinkLeveler returns the highest of the levels of the logger and the
+// minimum level of the sink.
+type sinkLeveler struct {
+	levels   *LevelVar
+	minLevel Level
+}
+
+func (l sinkLeveler) Level() slog.Level {
+	return max(l.levels.leveler().Level(), slog.Level(l.minLevel))
+}
+
+// newSinkHandler constructs the slog handler writing to the sink.
+func newSinkHandler(sink Sink, levels *LevelVar, replace func(groups []string, a slog.Attr) slog.Attr) slog.Handler {
+	opts := slog.HandlerOptions{
+		AddSource:   true,
+		Level:       sinkLeveler{levels: levels, minLevel: sink.MinLevel},
+		ReplaceAttr: replace,
+	}
+
+	switch sink.Format {
+	case FormatLogfmt:
+		return slog.NewTextHandler(sink.Writer, &opts)
+
+	case FormatText:
+		return newTextHandler(sink.Writer, &opts)
+	}
+
+	return slog.NewJSONHandler(sink.Writer, &opts)
+}
+
+// =============================================================================
+
+// multiHandler writes the records to every handler enabled for their level.
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+func newMultiHandler(handlers []slog.Handler) *multiHandler {
+	return &multiHandler{
+		handlers: handlers,
+	}
+}
+
+// Enabled reports whether any of the handlers handles records at the given
+// level.
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithAttrs returns a new handler whose handlers have the attributes.
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+
+	return &multiHandler{handlers: handlers}
+}
+
+// WithGroup returns a new handler whose handlers have the group.
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+
+	return &multiHandler{handlers: handlers}
+}
+
+// Handle writes the record to the handlers enabled for its level. A failing
+// handler doesn't keep the record from the others.
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// =============================================================================
+
+// textHandler writes the records for people to read, like:
+//
+//	2024-05-01T10:00:00.000Z INFO  request started file=logger.go:21 method=GET
+//
+// The attributes are formatted by a slog text handler, so they are written
+// the same way as logfmt.
+type textHandler struct {
+	w     io.Writer
+	mu    *sync.Mutex
+	buf   *bytes.Buffer
+	attrs slog.Handler
+}
+
+func newTextHandler(w io.Writer, opts *slog.HandlerOptions) *textHandler {
+	replace := opts.ReplaceAttr
+
+	// The time, level and message start the line, so they are dropped from
+	// the attributes.
+	f := func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch a.Key {
+			case slog.TimeKey, slog.LevelKey, slog.MessageKey:
+				return slog.Attr{}
+			}
+		}
+
+		if replace != nil {
+			return replace(groups, a)
+		}
+
+		return a
+	}
+
+	var buf bytes.Buffer
+
+	return &textHandler{
+		w:     w,
+		mu:    &sync.Mutex{},
+		buf:   &buf,
+		attrs: slog.NewTextHandler(&buf, &slog.HandlerOptions{AddSource: opts.AddSource, Level: opts.Level, ReplaceAttr: f}),
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *textHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.attrs.Enabled(ctx, level)
+}
+
+// WithAttrs returns a new handler with the attributes.
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textHandler{w: h.w, mu: h.mu, buf: h.buf, attrs: h.attrs.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new handler with the group.
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	return &textHandler{w: h.w, mu: h.mu, buf: h.buf, attrs: h.attrs.WithGroup(name)}
+}
+
+// Handle writes the record as a line.
+func (h *textHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.attrs.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%s %-5s %s %s", r.Time.Format("2006-01-02T15:04:05.000Z07:00"), r.Level, r.Message, h.buf.Bytes())
+
+	_, err := io.WriteString(h.w, line)
+	return err
+}
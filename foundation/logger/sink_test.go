@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	var jsonBuf, textBuf, logfmtBuf, errBuf bytes.Buffer
+
+	sinks := []Sink{
+		{Writer: &jsonBuf},
+		{Writer: &textBuf, Format: FormatText, MinLevel: LevelDebug},
+		{Writer: &logfmtBuf, Format: FormatLogfmt, MinLevel: LevelWarn},
+		{Writer: &errBuf, Format: FormatJSON, MinLevel: LevelError},
+	}
+
+	log := NewWithSinks(sinks, LevelDebug, "TEST", nil, Events{})
+
+	ctx := context.Background()
+	log.Debug(ctx, "debug message", "k", "v")
+	log.Error(ctx, "error message")
+
+	tests := []struct {
+		name string
+		buf  *bytes.Buffer
+		exp  []string
+		not  []string
+	}{
+		{"json", &jsonBuf, []string{`"msg":"error message"`, `"service":"TEST"`, `"file":"sink_test.go:`}, []string{"debug message"}},
+		{"text", &textBuf, []string{" DEBUG debug message file=sink_test.go:", "service=TEST k=v", " ERROR error message "}, []string{"msg="}},
+		{"logfmt", &logfmtBuf, []string{`msg="error message"`, "service=TEST", "file=sink_test.go:"}, []string{"debug message"}},
+		{"errors", &errBuf, []string{`"msg":"error message"`}, []string{"debug message"}},
+	}
+
+	for _, tt := range tests {
+		out := tt.buf.String()
+
+		for _, s := range tt.exp {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: Should write %q: %s", tt.name, s, out)
+			}
+		}
+
+		for _, s := range tt.not {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: Should not write %q: %s", tt.name, s, out)
+			}
+		}
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+
+	f, err := NewRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Should be able to open the file: %s", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Should be able to write: %s", err)
+		}
+	}
+
+	exp := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+
+	for p, content := range exp {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("Should be able to read %s: %s", p, err)
+		}
+
+		if string(data) != content {
+			t.Errorf("Should have %q in %s, got %q", content, p, data)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Should keep only 2 rotated files")
+	}
+}
@@ -0,0 +1,274 @@
+// Package openapi generates an OpenAPI 3.1 document from the routes
+// registered in a web.App.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// Set of annotation keys the generator understands. The route groups
+// document what their middleware enforce with web.Group.Annotate.
+const (
+	// AnnotationSecurity names the security scheme required by the route.
+	// The only supported scheme is "bearer".
+	AnnotationSecurity = "openapi.security"
+
+	// AnnotationAuthRule holds the authorization rule applied to the route.
+	AnnotationAuthRule = "auth.rule"
+)
+
+// Info represents the general information about the API.
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// Config represents the information used to generate a document.
+type Config struct {
+	Info Info
+
+	// Error is the type of the body of the error responses. When it's not
+	// set, error responses are documented without a body.
+	Error reflect.Type
+
+	// ProblemDetails documents the error responses as RFC 9457 Problem
+	// Details. The members of Error become the extension members of the
+	// problem, except its message which is reported as the detail.
+	ProblemDetails bool
+}
+
+// Generate builds the OpenAPI document for the routes and encodes it as
+// JSON. Routes registered without a method can't be described and are
+// skipped.
+func Generate(cfg Config, routes []web.Route) ([]byte, error) {
+	g := newGenerator()
+
+	doc := document{
+		OpenAPI: "3.1.0",
+		Info: info{
+			Title:       cfg.Info.Title,
+			Version:     cfg.Info.Version,
+			Description: cfg.Info.Description,
+		},
+		Paths: make(map[string]map[string]*operation),
+	}
+
+	errResp := response{Description: "Error"}
+	switch {
+	case cfg.ProblemDetails:
+		errResp.Content = map[string]mediaType{web.ProblemContentType: {Schema: g.problem(cfg.Error)}}
+
+	case cfg.Error != nil:
+		errResp.Content = map[string]mediaType{"application/json": {Schema: g.schema(cfg.Error)}}
+	}
+
+	var secured bool
+
+	for _, rt := range routes {
+		if rt.Method == "" {
+			continue
+		}
+
+		path := openAPIPath(rt.Pattern)
+
+		op := g.operation(rt, errResp)
+		if op.Security != nil {
+			secured = true
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operation)
+		}
+		doc.Paths[path][strings.ToLower(rt.Method)] = op
+	}
+
+	if len(g.schemas) > 0 || secured {
+		doc.Components = &components{Schemas: g.schemas}
+	}
+
+	if secured {
+		doc.Components.SecuritySchemes = map[string]securityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("openapi: encoding: %w", err)
+	}
+
+	return b, nil
+}
+
+// =============================================================================
+
+var (
+	wildcard = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+	anchor   = regexp.MustCompile(`\{\$\}$`)
+)
+
+// openAPIPath converts a ServeMux pattern into an OpenAPI path template.
+func openAPIPath(pattern string) string {
+	pattern = anchor.ReplaceAllString(pattern, "")
+	return wildcard.ReplaceAllString(pattern, "{$1}")
+}
+
+// pathParams returns the names of the wildcards of a ServeMux pattern.
+func pathParams(pattern string) []string {
+	var names []string
+	for _, m := range wildcard.FindAllStringSubmatch(pattern, -1) {
+		names = append(names, m[1])
+	}
+
+	return names
+}
+
+func (g *generator) operation(rt web.Route, errResp response) *operation {
+	op := operation{
+		Responses:  make(map[string]response),
+		Middleware: rt.Middleware,
+		AuthRule:   rt.Annotations[AnnotationAuthRule],
+	}
+
+	if rt.Annotations[AnnotationSecurity] == "bearer" {
+		op.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	// Parameters -------------------------------------------------------------
+
+	documented := make(map[string]bool)
+
+	if t := structType(rt.Request); t != nil {
+		for _, f := range fields(t) {
+			for _, in := range []string{"path", "query"} {
+				name := tagName(f, in)
+				if name == "" {
+					continue
+				}
+
+				s := g.fieldSchema(f)
+				op.Parameters = append(op.Parameters, parameter{
+					Name:     name,
+					In:       in,
+					Required: in == "path" || s.required,
+					Schema:   s.schema,
+				})
+				documented[in+":"+name] = true
+			}
+		}
+	}
+
+	for _, name := range pathParams(rt.Pattern) {
+		if documented["path:"+name] {
+			continue
+		}
+
+		op.Parameters = append(op.Parameters, parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &schema{Type: "string"},
+		})
+	}
+
+	// Request body -----------------------------------------------------------
+
+	if rt.Request != nil && hasBody(rt.Method) {
+		if s := g.bodySchema(rt.Request); s != nil {
+			op.RequestBody = &requestBody{
+				Required: true,
+				Content:  map[string]mediaType{"application/json": {Schema: s}},
+			}
+		}
+	}
+
+	// Responses --------------------------------------------------------------
+
+	status := rt.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	resp := response{Description: http.StatusText(status)}
+	if rt.Response != nil && status != http.StatusNoContent {
+		resp.Content = map[string]mediaType{"application/json": {Schema: g.schema(rt.Response)}}
+	}
+	op.Responses[fmt.Sprint(status)] = resp
+
+	op.Responses["default"] = errResp
+
+	return &op
+}
+
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	}
+
+	return true
+}
+
+// =============================================================================
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components *components                      `json:"components,omitempty"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type operation struct {
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Middleware  []string              `json:"x-middleware,omitempty"`
+	AuthRule    string                `json:"x-auth-rule,omitempty"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type components struct {
+	Schemas         map[string]*schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes,omitempty"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
@@ -0,0 +1,354 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/openapi"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+type appError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func TestGenerateErrorResponse(t *testing.T) {
+	routes := []web.Route{
+		{Method: "GET", Pattern: "/users"},
+	}
+
+	tests := []struct {
+		name           string
+		problemDetails bool
+		mediaType      string
+		schema         string
+		properties     []string
+	}{
+		{
+			name:       "application-error",
+			mediaType:  "application/json",
+			schema:     "#/components/schemas/appError",
+			properties: []string{"code", "fields", "message"},
+		},
+		{
+			name:           "problem",
+			problemDetails: true,
+			mediaType:      web.ProblemContentType,
+			schema:         "#/components/schemas/Problem",
+			properties:     []string{"code", "detail", "fields", "instance", "status", "title", "traceId", "type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := openapi.Config{
+				Error:          reflect.TypeFor[appError](),
+				ProblemDetails: tt.problemDetails,
+			}
+
+			doc := generate(t, cfg, routes)
+
+			content := doc.Paths["/users"]["get"].Responses["default"].Content
+			if len(content) != 1 {
+				t.Fatalf("Should document a single media type, got %v", content)
+			}
+
+			mt, exists := content[tt.mediaType]
+			if !exists {
+				t.Fatalf("Should document the %s media type, got %v", tt.mediaType, content)
+			}
+
+			if mt.Schema.Ref != tt.schema {
+				t.Errorf("Should reference %s, got %s", tt.schema, mt.Schema.Ref)
+			}
+
+			if got := doc.Components.Schemas[refName(tt.schema)].propertyNames(); !reflect.DeepEqual(got, tt.properties) {
+				t.Errorf("Should document the properties %v, got %v", tt.properties, got)
+			}
+		})
+	}
+}
+
+type listUsers struct {
+	ID    string `path:"id"`
+	Page  int    `query:"page" validate:"min=1"`
+	Name  string `json:"name" validate:"required,min=3,max=10"`
+	Email string `json:"email" validate:"email"`
+}
+
+type constraints struct {
+	ID      string   `json:"id" validate:"uuid"`
+	Role    string   `json:"role" validate:"oneof=admin user"`
+	Level   int      `json:"level" validate:"oneof=1 2 3"`
+	Code    string   `json:"code" validate:"regexp=^[a-z]{2,3}$"`
+	Tags    []string `json:"tags" validate:"min=1,oneof=a b"`
+	Pair    []int    `json:"pair" validate:"len=2"`
+	Percent float64  `json:"percent" validate:"min=0,max=100"`
+	Skipped string   `json:"-"`
+}
+
+type node struct {
+	Name     string `json:"name"`
+	Children []node `json:"children"`
+	Parent   *node  `json:"parent"`
+}
+
+// Route collides with the name of web.Route.
+type Route struct {
+	Path  string    `json:"path"`
+	Inner web.Route `json:"inner"`
+}
+
+func TestGeneratePaths(t *testing.T) {
+	routes := []web.Route{
+		{Method: "GET", Pattern: "/{$}"},
+		{Method: "GET", Pattern: "/files/{path...}"},
+		{Method: "GET", Pattern: "/users/{id}/orders/{order}"},
+		{Pattern: "/any"},
+	}
+
+	doc := generate(t, openapi.Config{}, routes)
+
+	exp := []string{"/", "/files/{path}", "/users/{id}/orders/{order}"}
+	var got []string
+	for path := range doc.Paths {
+		got = append(got, path)
+	}
+	slices.Sort(got)
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("Should document the paths %v, got %v", exp, got)
+	}
+
+	params := doc.Paths["/users/{id}/orders/{order}"]["get"].Parameters
+	expParams := []parameter{
+		{Name: "id", In: "path", Required: true, Schema: schema{Type: "string"}},
+		{Name: "order", In: "path", Required: true, Schema: schema{Type: "string"}},
+	}
+	if !reflect.DeepEqual(params, expParams) {
+		t.Errorf("Should document the path parameters:\ngot: %+v\nexp: %+v", params, expParams)
+	}
+
+	if params := doc.Paths["/files/{path}"]["get"].Parameters; len(params) != 1 || params[0].Name != "path" {
+		t.Errorf("Should document the path parameter of the wildcard, got %+v", params)
+	}
+}
+
+func TestGenerateBody(t *testing.T) {
+	routes := []web.Route{
+		{Method: "PUT", Pattern: "/users/{id}", Request: reflect.TypeFor[listUsers](), Status: 204},
+		{Method: "GET", Pattern: "/users/{id}", Request: reflect.TypeFor[listUsers](), Response: reflect.TypeFor[node]()},
+	}
+
+	doc := generate(t, openapi.Config{}, routes)
+
+	put := doc.Paths["/users/{id}"]["put"]
+
+	expParams := []parameter{
+		{Name: "id", In: "path", Required: true, Schema: schema{Type: "string"}},
+		{Name: "page", In: "query", Schema: schema{Type: "integer", Minimum: ptr(1.0)}},
+	}
+	if !reflect.DeepEqual(put.Parameters, expParams) {
+		t.Errorf("Should document the path and query parameters:\ngot: %+v\nexp: %+v", put.Parameters, expParams)
+	}
+
+	if put.RequestBody == nil {
+		t.Fatal("Should document the request body")
+	}
+
+	body := put.RequestBody.Content["application/json"].Schema
+	if got := body.propertyNames(); !reflect.DeepEqual(got, []string{"email", "name"}) {
+		t.Errorf("Should leave the path and query fields out of the body, got %v", got)
+	}
+
+	if _, exists := put.Responses["204"]; !exists {
+		t.Errorf("Should document the status of the route, got %v", put.Responses)
+	}
+
+	get := doc.Paths["/users/{id}"]["get"]
+	if get.RequestBody != nil {
+		t.Error("Should not document a body for a GET")
+	}
+
+	if got := get.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/node" {
+		t.Errorf("Should reference the response, got %q", got)
+	}
+}
+
+func TestGenerateConstraints(t *testing.T) {
+	routes := []web.Route{
+		{Method: "POST", Pattern: "/users", Request: reflect.TypeFor[listUsers]()},
+		{Method: "POST", Pattern: "/constraints", Request: reflect.TypeFor[constraints]()},
+	}
+
+	doc := generate(t, openapi.Config{}, routes)
+
+	// The path and query fields are left out, so the body is described
+	// inline.
+	users := doc.Paths["/users"]["post"].RequestBody.Content["application/json"].Schema
+
+	if !reflect.DeepEqual(users.Required, []string{"name"}) {
+		t.Errorf("Should require the name, got %v", users.Required)
+	}
+
+	body := doc.Paths["/constraints"]["post"].RequestBody.Content["application/json"].Schema
+	c := doc.Components.Schemas[refName(body.Ref)]
+
+	tests := []struct {
+		name string
+		got  schema
+		exp  schema
+	}{
+		{name: "name", got: users.Properties["name"], exp: schema{Type: "string", MinLength: ptr(3), MaxLength: ptr(10)}},
+		{name: "email", got: users.Properties["email"], exp: schema{Type: "string", Format: "email"}},
+		{name: "id", got: c.Properties["id"], exp: schema{Type: "string", Format: "uuid"}},
+		{name: "role", got: c.Properties["role"], exp: schema{Type: "string", Enum: []any{"admin", "user"}}},
+		{name: "level", got: c.Properties["level"], exp: schema{Type: "integer", Enum: []any{1.0, 2.0, 3.0}}},
+		{name: "code", got: c.Properties["code"], exp: schema{Type: "string", Pattern: "^[a-z]{2,3}$"}},
+		{name: "tags", got: c.Properties["tags"], exp: schema{Type: "array", MinItems: ptr(1), Items: &schema{Type: "string", Enum: []any{"a", "b"}}}},
+		{name: "pair", got: c.Properties["pair"], exp: schema{Type: "array", MinItems: ptr(2), MaxItems: ptr(2), Items: &schema{Type: "integer"}}},
+		{name: "percent", got: c.Properties["percent"], exp: schema{Type: "number", Minimum: ptr(0.0), Maximum: ptr(100.0)}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.exp) {
+			t.Errorf("%s: should apply the constraints:\ngot: %+v\nexp: %+v", tt.name, tt.got, tt.exp)
+		}
+	}
+
+	if _, exists := c.Properties["Skipped"]; exists {
+		t.Error("Should leave out the fields not encoded")
+	}
+}
+
+func TestGenerateComponents(t *testing.T) {
+	routes := []web.Route{
+		{Method: "GET", Pattern: "/nodes", Response: reflect.TypeFor[node]()},
+		{Method: "GET", Pattern: "/routes", Response: reflect.TypeFor[Route]()},
+		{
+			Method:      "GET",
+			Pattern:     "/secured",
+			Response:    reflect.TypeFor[[]node](),
+			Annotations: map[string]string{openapi.AnnotationSecurity: "bearer", openapi.AnnotationAuthRule: "rule_admin_only"},
+		},
+	}
+
+	doc := generate(t, openapi.Config{}, routes)
+
+	var names []string
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	if exp := []string{"Route", "node", "web.Route"}; !reflect.DeepEqual(names, exp) {
+		t.Fatalf("Should name the components %v, got %v", exp, names)
+	}
+
+	if got := doc.Components.Schemas["Route"].Properties["inner"].Ref; got != "#/components/schemas/web.Route" {
+		t.Errorf("Should reference the colliding type by its package, got %q", got)
+	}
+
+	n := doc.Components.Schemas["node"]
+	if got := n.Properties["parent"].Ref; got != "#/components/schemas/node" {
+		t.Errorf("Should reference the recursive type, got %q", got)
+	}
+	if got := n.Properties["children"].Items; got == nil || got.Ref != "#/components/schemas/node" {
+		t.Errorf("Should reference the recursive type in the items, got %+v", got)
+	}
+
+	secured := doc.Paths["/secured"]["get"]
+	if !reflect.DeepEqual(secured.Security, []map[string][]string{{"bearerAuth": {}}}) || secured.AuthRule != "rule_admin_only" {
+		t.Errorf("Should document the security of the route, got %v %q", secured.Security, secured.AuthRule)
+	}
+}
+
+// =============================================================================
+
+// document holds the parts of the generated document checked by the tests.
+type document struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas map[string]schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"responses"`
+	Security []map[string][]string `json:"security"`
+	AuthRule string                `json:"x-auth-rule"`
+}
+
+type parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema schema `json:"schema"`
+}
+
+type schema struct {
+	Ref        string            `json:"$ref"`
+	Type       string            `json:"type"`
+	Format     string            `json:"format"`
+	Pattern    string            `json:"pattern"`
+	Enum       []any             `json:"enum"`
+	Minimum    *float64          `json:"minimum"`
+	Maximum    *float64          `json:"maximum"`
+	MinLength  *int              `json:"minLength"`
+	MaxLength  *int              `json:"maxLength"`
+	MinItems   *int              `json:"minItems"`
+	MaxItems   *int              `json:"maxItems"`
+	Items      *schema           `json:"items"`
+	Properties map[string]schema `json:"properties"`
+	Required   []string          `json:"required"`
+}
+
+// propertyNames returns the names of the properties in order.
+func (s schema) propertyNames() []string {
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func generate(t *testing.T, cfg openapi.Config, routes []web.Route) document {
+	t.Helper()
+
+	b, err := openapi.Generate(cfg, routes)
+	if err != nil {
+		t.Fatalf("Should be able to generate the document: %s", err)
+	}
+
+	var doc document
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("Should be able to decode the document: %s", err)
+	}
+
+	return doc
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
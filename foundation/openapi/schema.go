@@ -0,0 +1,424 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schema represents the subset of JSON Schema 2020-12 used to describe the
+// request and response values.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// fieldSchema represents the schema of a struct field and whether the
+// field is required.
+type fieldSchema struct {
+	schema   *schema
+	required bool
+}
+
+// generator builds the schemas of the types found in the routes. Named
+// struct types are added to the components of the document and referenced.
+type generator struct {
+	schemas map[string]*schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+const (
+	componentsSchemaRef = "#/components/schemas/"
+	uuidPackage         = "github.com/google/uuid"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	bytesType         = reflect.TypeFor[[]byte]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	invalidNameChars  = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// schema returns the schema for the type.
+func (g *generator) schema(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+
+	case t.PkgPath() == uuidPackage && t.Name() == "UUID":
+		return &schema{Type: "string", Format: "uuid"}
+
+	case t == bytesType:
+		return &schema{Type: "string", Format: "byte"}
+
+	case implements(t, jsonMarshalerType):
+		return &schema{}
+
+	case implements(t, textMarshalerType):
+		return &schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &schema{Type: "string"}
+
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: g.schema(t.Elem())}
+
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.objectSchema(t, false)
+		}
+		return g.ref(t)
+	}
+
+	return &schema{}
+}
+
+// ref adds the named struct to the components and returns a reference.
+func (g *generator) ref(t reflect.Type) *schema {
+	if name, ok := g.names[t]; ok {
+		return &schema{Ref: componentsSchemaRef + name}
+	}
+
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if _, exists := g.schemas[name]; exists {
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[i+1:]
+		}
+		name = pkg + "." + name
+	}
+
+	// The name is registered before the properties are built so recursive
+	// types end up referencing themselves.
+	g.names[t] = name
+	g.schemas[name] = &schema{}
+	*g.schemas[name] = *g.objectSchema(t, false)
+
+	return &schema{Ref: componentsSchemaRef + name}
+}
+
+// problem adds the Problem Details document to the components and returns a
+// reference. It must be called before any other type is added. The members
+// of the error type, other than its message, are documented as extension
+// members.
+func (g *generator) problem(errType reflect.Type) *schema {
+	s := schema{
+		Type: "object",
+		Properties: map[string]*schema{
+			"type":     {Type: "string", Format: "uri-reference"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string", Format: "uri-reference"},
+			"traceId":  {Type: "string"},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+
+	if t := structType(errType); t != nil {
+		ext := g.objectSchema(t, false)
+		for name, p := range ext.Properties {
+			if _, exists := s.Properties[name]; exists || name == "message" {
+				continue
+			}
+			s.Properties[name] = p
+		}
+	}
+
+	// The problem is added before the types of the routes, which are named
+	// after their package when they collide with it.
+	g.schemas["Problem"] = &s
+
+	return &schema{Ref: componentsSchemaRef + "Problem"}
+}
+
+// objectSchema builds the schema of the struct. When bodyOnly is set, the
+// fields bound from the path and the query string are left out.
+func (g *generator) objectSchema(t reflect.Type, bodyOnly bool) *schema {
+	s := schema{
+		Type:       "object",
+		Properties: make(map[string]*schema),
+	}
+
+	for _, f := range fields(t) {
+		if bodyOnly && (tagName(f, "path") != "" || tagName(f, "query") != "") {
+			continue
+		}
+
+		name := jsonName(f)
+		if name == "" {
+			continue
+		}
+
+		fs := g.fieldSchema(f)
+		s.Properties[name] = fs.schema
+		if fs.required {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return &s
+}
+
+// bodySchema returns the schema of the request body, which leaves out the
+// fields bound from the path and the query string. A nil schema means the
+// request has no body.
+func (g *generator) bodySchema(t reflect.Type) *schema {
+	st := structType(t)
+	if st == nil {
+		return g.schema(t)
+	}
+
+	var bound bool
+	for _, f := range fields(st) {
+		if tagName(f, "path") != "" || tagName(f, "query") != "" {
+			bound = true
+			break
+		}
+	}
+
+	if !bound {
+		if len(fields(st)) == 0 {
+			return nil
+		}
+		return g.schema(t)
+	}
+
+	s := g.objectSchema(st, true)
+	if len(s.Properties) == 0 {
+		return nil
+	}
+
+	return s
+}
+
+// fieldSchema returns the schema of the field with the constraints of its
+// validate tag applied.
+func (g *generator) fieldSchema(f reflect.StructField) fieldSchema {
+	fs := fieldSchema{schema: g.schema(f.Type)}
+
+	tag := f.Tag.Get("validate")
+	if tag == "" {
+		return fs
+	}
+
+	// Constraints can't be added next to a reference, so the referenced
+	// schema is left as it is.
+	if fs.schema.Ref != "" {
+		fs.required = strings.Contains(","+tag+",", ",required,")
+		return fs
+	}
+
+	s := *fs.schema
+	fs.schema = &s
+
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "regexp=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+
+		switch name {
+		case "required":
+			fs.required = true
+
+		case "min", "max", "len":
+			applyBound(&s, name, param)
+
+		case "email":
+			s.Format = "email"
+
+		case "uuid":
+			s.Format = "uuid"
+
+		case "regexp":
+			s.Pattern = param
+
+		case "oneof":
+			target := &s
+			if s.Type == "array" && s.Items != nil {
+				items := *s.Items
+				s.Items = &items
+				target = &items
+			}
+
+			for _, v := range strings.Fields(param) {
+				if target.Type == "integer" {
+					if n, err := strconv.Atoi(v); err == nil {
+						target.Enum = append(target.Enum, n)
+						continue
+					}
+				}
+				target.Enum = append(target.Enum, v)
+			}
+		}
+	}
+
+	return fs
+}
+
+// applyBound sets the length or value limits of a min, max or len rule.
+func applyBound(s *schema, name string, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	i := int(n)
+
+	switch s.Type {
+	case "string":
+		switch name {
+		case "min":
+			s.MinLength = &i
+		case "max":
+			s.MaxLength = &i
+		case "len":
+			s.MinLength, s.MaxLength = &i, &i
+		}
+
+	case "array":
+		switch name {
+		case "min":
+			s.MinItems = &i
+		case "max":
+			s.MaxItems = &i
+		case "len":
+			s.MinItems, s.MaxItems = &i, &i
+		}
+
+	case "integer", "number":
+		switch name {
+		case "min":
+			s.Minimum = &n
+		case "max":
+			s.Maximum = &n
+		case "len":
+			s.Minimum, s.Maximum = &n, &n
+		}
+	}
+}
+
+// =============================================================================
+
+// fields returns the exported fields of the struct. The fields of embedded
+// structs without a json name are promoted, like encoding/json does.
+func fields(t reflect.Type) []reflect.StructField {
+	var fs []reflect.StructField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if ft.Kind() == reflect.Struct && name == "" {
+				fs = append(fs, fields(ft)...)
+				continue
+			}
+		}
+
+		if f.IsExported() {
+			fs = append(fs, f)
+		}
+	}
+
+	return fs
+}
+
+// jsonName returns the name of the field in the JSON document, or an empty
+// string when the field is not encoded.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+
+	return name
+}
+
+// tagName returns the name of the field in the tag, like path or query.
+func tagName(f reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// structType returns the struct type behind the type, or nil.
+func structType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+
+	return t
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
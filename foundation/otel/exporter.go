@@ -0,0 +1,106 @@
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// writerExporter is a span exporter that writes every span as a JSON document
+// on its own line. It allows tracing to be used offline, writing to stderr or
+// to a file that can be inspected or shipped later.
+type writerExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// newWriterExporter constructs an exporter that writes to w. The closer is
+// optional and is closed when the exporter is shut down.
+func newWriterExporter(w io.Writer, closer io.Closer) *writerExporter {
+	return &writerExporter{
+		w:      w,
+		closer: closer,
+	}
+}
+
+// span represents the data written for every exported span.
+type span struct {
+	Service      string         `json:"service,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Duration     string         `json:"duration"`
+	Status       string         `json:"status"`
+	Description  string         `json:"description,omitempty"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+// ExportSpans writes the batch of spans to the writer.
+func (e *writerExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+
+	for _, s := range spans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		v := span{
+			Name:        s.Name(),
+			Kind:        s.SpanKind().String(),
+			TraceID:     s.SpanContext().TraceID().String(),
+			SpanID:      s.SpanContext().SpanID().String(),
+			Start:       s.StartTime(),
+			End:         s.EndTime(),
+			Duration:    s.EndTime().Sub(s.StartTime()).String(),
+			Status:      s.Status().Code.String(),
+			Description: s.Status().Description,
+		}
+
+		if s.Parent().IsValid() {
+			v.ParentSpanID = s.Parent().SpanID().String()
+		}
+
+		if res := s.Resource(); res != nil {
+			if name, ok := res.Set().Value("service.name"); ok {
+				v.Service = name.AsString()
+			}
+		}
+
+		if attrs := s.Attributes(); len(attrs) > 0 {
+			v.Attributes = make(map[string]any, len(attrs))
+			for _, attr := range attrs {
+				v.Attributes[string(attr.Key)] = attr.Value.AsInterface()
+			}
+		}
+
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown closes the underlying writer if it needs to be closed.
+func (e *writerExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+
+	return e.closer.Close()
+}
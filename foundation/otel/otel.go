@@ -0,0 +1,144 @@
+// Package otel provides support for the OpenTelemetry tracing system.
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/zucchini/services-golang/foundation/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Set of exporters that can be configured.
+const (
+	ExporterNone   = "none"
+	ExporterStderr = "stderr"
+	ExporterFile   = "file"
+)
+
+// Config defines the information needed to init tracing.
+type Config struct {
+	ServiceName string
+	Exporter    string
+	File        string
+	Probability float64
+}
+
+// InitTracing configures open telemetry to be used with the service. The
+// returned function must be called on shutdown to flush the pending spans.
+func InitTracing(log *logger.Logger, cfg Config) (trace.TracerProvider, func(ctx context.Context), error) {
+
+	// WARNING: The current settings are using defaults which may not be
+	// compatible with your project. Please review the documentation for
+	// opentelemetry.
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exporter *writerExporter
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		log.Info(context.Background(), "OTEL", "tracer", "NOOP")
+
+		return noop.NewTracerProvider(), func(ctx context.Context) {}, nil
+
+	// The logs are written to stdout, so the spans go to stderr to not be
+	// mixed with them.
+	case ExporterStderr:
+		exporter = newWriterExporter(os.Stderr, nil)
+
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening trace file: %w", err)
+		}
+		exporter = newWriterExporter(f, f)
+
+	default:
+		return nil, nil, fmt.Errorf("unknown exporter %q", cfg.Exporter)
+	}
+
+	traceProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Probability))),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(
+			resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceName(cfg.ServiceName),
+			),
+		),
+	)
+
+	teardown := func(ctx context.Context) {
+		traceProvider.Shutdown(ctx)
+	}
+
+	log.Info(context.Background(), "OTEL", "tracer", cfg.Exporter, "probability", cfg.Probability)
+
+	return traceProvider, teardown, nil
+}
+
+// InjectTracing saves the tracer into the context so spans can be added
+// by the different layers handling the request.
+func InjectTracing(ctx context.Context, tracer trace.Tracer) context.Context {
+	return setTracer(ctx, tracer)
+}
+
+// ExtractTrace returns a context that carries the remote span found in the
+// specified headers, if any.
+func ExtractTrace(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// AddSpan adds an otel span to the existing trace.
+func AddSpan(ctx context.Context, spanName string, keyValues ...attribute.KeyValue) (context.Context, trace.Span) {
+	return addSpan(ctx, spanName, trace.SpanKindInternal, keyValues...)
+}
+
+// AddClientSpan adds an otel span to the existing trace that represents a
+// call made to a remote service.
+func AddClientSpan(ctx context.Context, spanName string, keyValues ...attribute.KeyValue) (context.Context, trace.Span) {
+	return addSpan(ctx, spanName, trace.SpanKindClient, keyValues...)
+}
+
+// AddTraceToRequest adds the current trace id to the request so it
+// can be delivered to the service being called.
+func AddTraceToRequest(ctx context.Context, r *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+func addSpan(ctx context.Context, spanName string, kind trace.SpanKind, keyValues ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer, ok := getTracer(ctx)
+	if !ok {
+		return ctx, noop.Span{}
+	}
+
+	ctx, span := tracer.Start(ctx, spanName, trace.WithSpanKind(kind))
+	span.SetAttributes(keyValues...)
+
+	return ctx, span
+}
+
+// =============================================================================
+
+type ctxKey int
+
+const tracerKey ctxKey = 1
+
+func setTracer(ctx context.Context, tracer trace.Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey, tracer)
+}
+
+func getTracer(ctx context.Context) (trace.Tracer, bool) {
+	v, ok := ctx.Value(tracerKey).(trace.Tracer)
+	return v, ok
+}
@@ -0,0 +1,213 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// rule represents a single rule of a validate tag. The check function
+// returns the message to report, or an empty string when the value is valid.
+type rule struct {
+	name  string
+	check func(v reflect.Value) string
+}
+
+// parseRules parses the rules declared in a validate tag.
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+
+	for tag != "" {
+		var item string
+
+		// The expression of a regexp rule may contain commas, so it takes
+		// the rest of the tag.
+		if strings.HasPrefix(tag, "regexp=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+
+		r, err := newRule(name, param)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+func newRule(name string, param string) (rule, error) {
+	switch name {
+	case "required":
+		return rule{name: name, check: required}, nil
+
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid %s parameter %q", name, param)
+		}
+		return rule{name: name, check: bound(name, n)}, nil
+
+	case "email":
+		return rule{name: name, check: email}, nil
+
+	case "uuid":
+		return rule{name: name, check: isUUID}, nil
+
+	case "oneof":
+		values := strings.Fields(param)
+		if len(values) == 0 {
+			return rule{}, fmt.Errorf("oneof requires at least one value")
+		}
+		return rule{name: name, check: oneOf(values)}, nil
+
+	case "regexp":
+		re, err := regexp.Compile(param)
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid regexp parameter: %w", err)
+		}
+		return rule{name: name, check: match(re)}, nil
+	}
+
+	return rule{}, fmt.Errorf("unknown rule %q", name)
+}
+
+// =============================================================================
+
+func required(v reflect.Value) string {
+	if !v.IsValid() || v.IsZero() {
+		return "is required"
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return "is required"
+	}
+
+	return ""
+}
+
+// bound checks the length of strings and collections, and the value of
+// numbers, against the limit.
+func bound(name string, limit float64) func(v reflect.Value) string {
+	return func(v reflect.Value) string {
+		var n float64
+		var unit string
+
+		switch v.Kind() {
+		case reflect.String:
+			n, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n, unit = float64(v.Len()), " items"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		default:
+			return ""
+		}
+
+		limitStr := strconv.FormatFloat(limit, 'f', -1, 64)
+
+		switch {
+		case name == "min" && n < limit:
+			if unit == "" {
+				return "must be " + limitStr + " or greater"
+			}
+			return "must contain at least " + limitStr + unit
+
+		case name == "max" && n > limit:
+			if unit == "" {
+				return "must be " + limitStr + " or less"
+			}
+			return "must contain at most " + limitStr + unit
+
+		case name == "len" && n != limit:
+			return "must contain exactly " + limitStr + unit
+		}
+
+		return ""
+	}
+}
+
+func email(v reflect.Value) string {
+	if v.Kind() != reflect.String {
+		return ""
+	}
+
+	addr, err := mail.ParseAddress(v.String())
+	if err != nil || addr.Address != v.String() {
+		return "must be a valid email address"
+	}
+
+	return ""
+}
+
+func isUUID(v reflect.Value) string {
+	if v.Kind() != reflect.String {
+		return ""
+	}
+
+	if _, err := uuid.Parse(v.String()); err != nil {
+		return "must be a valid uuid"
+	}
+
+	return ""
+}
+
+// oneOf checks the value is one of the allowed values. For collections,
+// every item is checked.
+func oneOf(values []string) func(v reflect.Value) string {
+	msg := "must be one of [" + strings.Join(values, " ") + "]"
+
+	var check func(v reflect.Value) string
+	check = func(v reflect.Value) string {
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := range v.Len() {
+				if check(indirect(v.Index(i))) != "" {
+					return msg
+				}
+			}
+			return ""
+
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if !slices.Contains(values, fmt.Sprint(v.Interface())) {
+				return msg
+			}
+		}
+
+		return ""
+	}
+
+	return check
+}
+
+func match(re *regexp.Regexp) func(v reflect.Value) string {
+	return func(v reflect.Value) string {
+		if v.Kind() != reflect.String {
+			return ""
+		}
+
+		if !re.MatchString(v.String()) {
+			return "must match " + re.String()
+		}
+
+		return ""
+	}
+}
@@ -0,0 +1,268 @@
+// Package validate provides struct validation driven by the validate tag.
+// Every failing field is reported at once so the client can fix the whole
+// request in a single round trip.
+//
+//	type NewUser struct {
+//		Name  string   `json:"name" validate:"required,min=3,max=64"`
+//		Email string   `json:"email" validate:"required,email"`
+//		Roles []string `json:"roles" validate:"required,oneof=ADMIN USER"`
+//	}
+//
+// Supported rules: required, min, max, len, email, uuid, oneof and regexp.
+// Rules other than required are skipped when the value is unset, which is
+// a nil pointer, an empty string, slice or map, or a zero struct, so
+// optional fields can be left out. Numbers and booleans are always set, so
+// min=1 on an int rejects 0. The regexp rule must be the last rule
+// of the tag since the expression may contain commas.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldError represents a validation failure for a single field. The field
+// name is taken from the json tag, or else the query or path tag, and nested
+// fields use a dotted path.
+type FieldError struct {
+	Field string `json:"field"`
+	Err   string `json:"error"`
+}
+
+// FieldErrors represents the collection of field validation failures.
+type FieldErrors []FieldError
+
+// Error implements the error interface.
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, f := range fe {
+		msgs[i] = f.Field + ": " + f.Err
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Fields returns the failures as a map of field names to messages.
+func (fe FieldErrors) Fields() map[string]string {
+	m := make(map[string]string, len(fe))
+	for _, f := range fe {
+		m[f.Field] = f.Err
+	}
+
+	return m
+}
+
+// IsFieldErrors checks if the error chain contains FieldErrors.
+func IsFieldErrors(err error) bool {
+	var fe FieldErrors
+	return errors.As(err, &fe)
+}
+
+// GetFieldErrors returns the FieldErrors in the error chain, if any.
+func GetFieldErrors(err error) FieldErrors {
+	var fe FieldErrors
+	if !errors.As(err, &fe) {
+		return nil
+	}
+	return fe
+}
+
+// =============================================================================
+
+// Check validates the value against the rules declared in the validate tags
+// of its fields. The value must be a struct or a pointer to a struct. A
+// FieldErrors value is returned when one or more fields fail validation.
+// Any other error means the tags are invalid.
+func Check(val any) error {
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fe FieldErrors
+	if err := checkStruct(v, "", &fe); err != nil {
+		return err
+	}
+
+	if len(fe) > 0 {
+		return fe
+	}
+
+	return nil
+}
+
+func checkStruct(v reflect.Value, prefix string, fe *FieldErrors) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if err := checkValue(v.Field(f.index), prefix+f.name, f.rules, fe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkValue(v reflect.Value, path string, rules []rule, fe *FieldErrors) error {
+	empty := unset(v)
+
+	for _, r := range rules {
+		if r.name != "required" && empty {
+			continue
+		}
+
+		if msg := r.check(indirect(v)); msg != "" {
+			*fe = append(*fe, FieldError{Field: path, Err: msg})
+			break
+		}
+	}
+
+	// Nested structs, and slices of structs, are validated as well so
+	// their failures are reported with the full path.
+	v = indirect(v)
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return checkStruct(v, path+".", fe)
+
+	case reflect.Slice, reflect.Array:
+		if !isStruct(v.Type().Elem()) {
+			return nil
+		}
+
+		for i := range v.Len() {
+			ev := indirect(v.Index(i))
+			if ev.Kind() != reflect.Struct {
+				continue
+			}
+
+			if err := checkStruct(ev, path+"["+strconv.Itoa(i)+"].", fe); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// unset reports whether the value was left out. Numbers and booleans are
+// never unset since their zero value is a valid value to check.
+func unset(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return false
+	}
+
+	return v.IsZero()
+}
+
+// =============================================================================
+
+// field represents an exported struct field with its parsed rules.
+type field struct {
+	index int
+	name  string
+	rules []rule
+}
+
+// cache holds the parsed fields for every struct type already validated.
+var cache sync.Map
+
+type cacheEntry struct {
+	fields []field
+	err    error
+}
+
+func structFields(t reflect.Type) ([]field, error) {
+	if e, ok := cache.Load(t); ok {
+		entry := e.(cacheEntry)
+		return entry.fields, entry.err
+	}
+
+	var fields []field
+	var err error
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		rules, perr := parseRules(sf.Tag.Get("validate"))
+		if perr != nil {
+			err = fmt.Errorf("validate: %s.%s: %w", t.Name(), sf.Name, perr)
+			break
+		}
+
+		if len(rules) == 0 && !isStruct(sf.Type) && !isStructSlice(sf.Type) {
+			continue
+		}
+
+		fields = append(fields, field{index: i, name: fieldName(sf), rules: rules})
+	}
+
+	cache.Store(t, cacheEntry{fields: fields, err: err})
+
+	return fields, err
+}
+
+// fieldName returns the name used to report the field, which is the json
+// name when the field has one, or else the query or path name it's bound
+// from, so the conversion and the rule failures of a field share its name.
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "path"} {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return sf.Name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
+
+func isStructSlice(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && isStruct(t.Elem())
+}
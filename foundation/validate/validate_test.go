@@ -0,0 +1,117 @@
+package validate_test
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/validate"
+)
+
+type address struct {
+	ZipCode string `json:"zipCode" validate:"required,len=5"`
+}
+
+type user struct {
+	ID        string    `json:"id" validate:"uuid"`
+	Name      string    `json:"name" validate:"required,min=3,max=10"`
+	Email     string    `json:"email" validate:"required,email"`
+	Age       int       `json:"age" validate:"min=18,max=130"`
+	Roles     []string  `json:"roles" validate:"required,oneof=ADMIN USER"`
+	Code      string    `json:"code" validate:"regexp=^[A-Z]{2,3}$"`
+	Address   address   `json:"address"`
+	Addresses []address `json:"addresses"`
+	Nickname  *string   `json:"nickname" validate:"min=2"`
+}
+
+func TestCheck(t *testing.T) {
+	nick := "x"
+
+	testCases := []struct {
+		name   string
+		user   user
+		fields map[string]string
+	}{
+		{
+			name: "valid",
+			user: user{
+				ID:      "5cf37266-3473-4006-984f-9325122678b7",
+				Name:    "Bill",
+				Email:   "bill@example.com",
+				Age:     30,
+				Roles:   []string{"ADMIN"},
+				Code:    "ES",
+				Address: address{ZipCode: "28001"},
+			},
+		},
+		{
+			name: "invalid",
+			user: user{
+				ID:        "1234",
+				Name:      "Bi",
+				Email:     "bill",
+				Age:       10,
+				Roles:     []string{"ADMIN", "ROOT"},
+				Code:      "ES,1",
+				Addresses: []address{{ZipCode: "28001"}, {ZipCode: "123"}},
+				Nickname:  &nick,
+			},
+			fields: map[string]string{
+				"id":                   "must be a valid uuid",
+				"name":                 "must contain at least 3 characters",
+				"email":                "must be a valid email address",
+				"age":                  "must be 18 or greater",
+				"roles":                "must be one of [ADMIN USER]",
+				"code":                 "must match ^[A-Z]{2,3}$",
+				"address.zipCode":      "is required",
+				"addresses[1].zipCode": "must contain exactly 5 characters",
+				"nickname":             "must contain at least 2 characters",
+			},
+		},
+		{
+			name: "zero-number",
+			user: user{
+				Name:    "Bill",
+				Email:   "bill@example.com",
+				Roles:   []string{"USER"},
+				Address: address{ZipCode: "28001"},
+			},
+			fields: map[string]string{
+				"age": "must be 18 or greater",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate.Check(&tc.user)
+
+			if tc.fields == nil {
+				if err != nil {
+					t.Fatalf("Should be able to validate the value: %s", err)
+				}
+				return
+			}
+
+			var fe validate.FieldErrors
+			if !errors.As(err, &fe) {
+				t.Fatalf("Should receive field errors, got %v", err)
+			}
+
+			if got := fe.Fields(); !maps.Equal(got, tc.fields) {
+				t.Errorf("Should report every failing field:\ngot: %v\nexp: %v", got, tc.fields)
+			}
+		})
+	}
+}
+
+func TestCheckInvalidTag(t *testing.T) {
+	type bad struct {
+		Name string `validate:"unknown"`
+	}
+
+	err := validate.Check(bad{})
+	if err == nil || validate.IsFieldErrors(err) {
+		t.Fatalf("Should receive a tag error, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package web
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/zucchini/services-golang/foundation/validate"
+)
+
+// DecodeQuery binds the query string of the request into the fields of the
+// struct tagged with query. Repeated keys fill slice fields, and so do comma
+// separated values when the tag has the split option. Fields without a value
+// in the query string keep their current value, so defaults can be set
+// before the call, and an empty value like ?page= counts as no value. Values
+// that can't be converted are reported like any other validation failure in
+// a validate.FieldErrors error.
+//
+//	type QueryParams struct {
+//		Page    int        `query:"page" validate:"min=1"`
+//		Rows    int        `query:"rows" validate:"min=1,max=100"`
+//		OrderBy string     `query:"orderBy" validate:"oneof=name email"`
+//		UserID  *uuid.UUID `query:"userId"`
+//		Roles   []string   `query:"roles,split"`
+//	}
+func DecodeQuery(r *http.Request, val any) error {
+	if err := bind(val, "query", queryLookup(r)); err != nil {
+		return err
+	}
+
+	return check(val)
+}
+
+// DecodePath binds the path parameters of the request into the fields of
+// the struct tagged with path. It follows the same rules as DecodeQuery.
+//
+//	type PathParams struct {
+//		UserID uuid.UUID `path:"user_id"`
+//	}
+func DecodePath(r *http.Request, val any) error {
+	if err := bind(val, "path", pathLookup(r)); err != nil {
+		return err
+	}
+
+	return check(val)
+}
+
+func queryLookup(r *http.Request) func(name string) ([]string, bool) {
+	query := r.URL.Query()
+
+	return func(name string) ([]string, bool) {
+		var values []string
+		for _, v := range query[name] {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+
+		return values, len(values) > 0
+	}
+}
+
+func pathLookup(r *http.Request) func(name string) ([]string, bool) {
+	return func(name string) ([]string, bool) {
+		v := r.PathValue(name)
+		if v == "" {
+			return nil, false
+		}
+
+		return []string{v}, true
+	}
+}
+
+// bind sets the fields of the struct tagged with the tag name using the
+// values returned by the lookup function. The struct is not validated.
+func bind(val any, tag string, lookup func(name string) ([]string, bool)) error {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: %T is not a pointer to a struct", val)
+	}
+	v = v.Elem()
+
+	var fe validate.FieldErrors
+
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+
+		name, opts, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+
+		values, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		if opts == "split" {
+			values = splitValues(values)
+			if len(values) == 0 {
+				continue
+			}
+		}
+
+		if err := setField(v.Field(i), values); err != nil {
+			fe = append(fe, validate.FieldError{Field: name, Err: err.Error()})
+		}
+	}
+
+	if len(fe) > 0 {
+		return fe
+	}
+
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setField converts the values into the type of the field.
+func setField(field reflect.Value, values []string) error {
+	switch {
+	case reflect.PointerTo(field.Type()).Implements(textUnmarshalerType):
+		return setValue(field, values[len(values)-1])
+
+	case field.Kind() == reflect.Pointer:
+		ptr := reflect.New(field.Type().Elem())
+		if err := setField(ptr.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+
+	case field.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, s := range values {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return setValue(field, values[len(values)-1])
+}
+
+// splitValues splits the comma separated values, dropping the empty ones.
+func splitValues(values []string) []string {
+	var items []string
+	for _, v := range values {
+		for item := range strings.SplitSeq(v, ",") {
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+// setValue converts a single value into the type of the field.
+func setValue(field reflect.Value, s string) error {
+	if tu, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := tu.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid value %q", s)
+		}
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		field.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(n)
+
+	default:
+		return errors.New("unsupported type " + field.Type().String())
+	}
+
+	return nil
+}
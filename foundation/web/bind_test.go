@@ -0,0 +1,126 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/validate"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+type queryParams struct {
+	Page   int      `query:"page"`
+	Limit  *int     `query:"limit"`
+	Tags   []string `query:"tags"`
+	Roles  []string `query:"roles,split"`
+	Active bool     `query:"active"`
+}
+
+func TestDecodeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		exp   queryParams
+	}{
+		{name: "default", query: "", exp: queryParams{Page: 1}},
+		{name: "empty", query: "page=&limit=&roles=&tags=", exp: queryParams{Page: 1}},
+		{name: "values", query: "page=2&limit=10&active=true", exp: queryParams{Page: 2, Limit: new(int), Active: true}},
+		{name: "repeated", query: "tags=a&tags=&tags=b", exp: queryParams{Page: 1, Tags: []string{"a", "b"}}},
+		{name: "no-split", query: "tags=a,b", exp: queryParams{Page: 1, Tags: []string{"a,b"}}},
+		{name: "split", query: "roles=ADMIN,,USER&roles=GUEST", exp: queryParams{Page: 1, Roles: []string{"ADMIN", "USER", "GUEST"}}},
+		{name: "split-empty", query: "roles=,", exp: queryParams{Page: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+
+			qp := queryParams{Page: 1}
+			if err := web.DecodeQuery(r, &qp); err != nil {
+				t.Fatalf("Should be able to decode the query: %s", err)
+			}
+
+			if qp.Page != tt.exp.Page || qp.Active != tt.exp.Active {
+				t.Errorf("Should decode the values, got %+v", qp)
+			}
+
+			if (qp.Limit == nil) != (tt.exp.Limit == nil) {
+				t.Errorf("Should only set the pointer when there is a value, got %v", qp.Limit)
+			}
+
+			if !slices.Equal(qp.Tags, tt.exp.Tags) || (qp.Tags == nil) != (tt.exp.Tags == nil) {
+				t.Errorf("Should decode the tags %q, got %q", tt.exp.Tags, qp.Tags)
+			}
+
+			if !slices.Equal(qp.Roles, tt.exp.Roles) || (qp.Roles == nil) != (tt.exp.Roles == nil) {
+				t.Errorf("Should decode the roles %q, got %q", tt.exp.Roles, qp.Roles)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users?page=one&active=maybe", nil)
+
+		var qp queryParams
+		err := web.DecodeQuery(r, &qp)
+
+		fields := validate.GetFieldErrors(err).Fields()
+		if fields["page"] == "" || fields["active"] == "" {
+			t.Fatalf("Should report the fields that can't be converted, got %v", err)
+		}
+	})
+
+	t.Run("same-field-name", func(t *testing.T) {
+		type pageParams struct {
+			Page int `query:"page" validate:"min=1"`
+		}
+
+		// The conversion and the rule failures are reported under the
+		// name of the query parameter.
+		tests := []struct {
+			query string
+			exp   string
+		}{
+			{query: "page=x", exp: "must be an integer"},
+			{query: "page=0", exp: "must be 1 or greater"},
+		}
+
+		for _, tt := range tests {
+			r := httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+
+			qp := pageParams{Page: 1}
+			err := web.DecodeQuery(r, &qp)
+
+			got := validate.GetFieldErrors(err).Fields()
+			if len(got) != 1 || !strings.Contains(got["page"], tt.exp) {
+				t.Errorf("%s: should report the page field, got %v", tt.query, got)
+			}
+		}
+	})
+}
+
+func TestDecodePath(t *testing.T) {
+	type pathParams struct {
+		ID int `path:"id"`
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)})
+
+	var pp pathParams
+	var handlerErr error
+	app.HandleFunc("GET /users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		handlerErr = web.DecodePath(r, &pp)
+		return nil
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if handlerErr != nil || pp.ID != 42 {
+		t.Fatalf("Should decode the path parameters, got %+v: %v", pp, handlerErr)
+	}
+}
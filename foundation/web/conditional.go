@@ -0,0 +1,197 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrPreconditionFailed is returned when the If-Match header of a request
+// doesn't match the current ETag of the resource.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// contentCodings are the content codings an ETag can be suffixed with by
+// EncodedETag.
+var contentCodings = []string{"gzip", "deflate", "br", "zstd"}
+
+// RespondOptions represents the options that can be set when responding.
+type RespondOptions struct {
+	etag         string
+	computeETag  bool
+	weakETag     bool
+	lastModified time.Time
+}
+
+// WithETag sets the ETag of the response, like one built from the version
+// of the resource. A value without quotes is quoted.
+func WithETag(etag string) func(opts *RespondOptions) {
+	return func(opts *RespondOptions) {
+		opts.etag = quoteETag(etag)
+	}
+}
+
+// ComputeETag sets a strong ETag computed from the encoded body.
+func ComputeETag() func(opts *RespondOptions) {
+	return func(opts *RespondOptions) {
+		opts.computeETag = true
+		opts.weakETag = false
+	}
+}
+
+// ComputeWeakETag sets a weak ETag computed from the encoded body, for
+// representations that are equivalent but not byte for byte identical.
+func ComputeWeakETag() func(opts *RespondOptions) {
+	return func(opts *RespondOptions) {
+		opts.computeETag = true
+		opts.weakETag = true
+	}
+}
+
+// WithLastModified sets the Last-Modified header of the response.
+func WithLastModified(t time.Time) func(opts *RespondOptions) {
+	return func(opts *RespondOptions) {
+		opts.lastModified = t
+	}
+}
+
+// CheckIfMatch validates the If-Match header of the request against the
+// current ETag of the resource, so an update based on a stale copy is
+// rejected with ErrPreconditionFailed. An empty ETag means the resource
+// doesn't exist. Requests without the header are always allowed.
+func CheckIfMatch(ctx context.Context, etag string) error {
+	r := getRequest(ctx)
+	if r == nil {
+		return nil
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return nil
+	}
+
+	if etag != "" {
+		etag = quoteETag(etag)
+
+		for _, tag := range splitETags(ifMatch) {
+			if tag == "*" || (!isWeak(tag) && !isWeak(etag) && stripCoding(tag) == etag) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: if-match %s", ErrPreconditionFailed, ifMatch)
+}
+
+// EncodedETag returns the ETag of the representation encoded with the
+// content coding, like "v1-gzip" for "v1". The validator stays strong while
+// caches tell the compressed bytes from the identity ones, and the suffix is
+// removed before the ETags sent back by the clients are compared. Weak ETags
+// already cover equivalent representations, so they are returned as is.
+func EncodedETag(etag string, coding string) string {
+	if etag == "" || isWeak(etag) || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+
+	return strings.TrimSuffix(etag, `"`) + "-" + coding + `"`
+}
+
+// =============================================================================
+
+// conditional sets the validators of the response and reports if the
+// client already has the representation, in which case the response is a
+// 304 without a body.
+func conditional(r *http.Request, w http.ResponseWriter, body []byte, statusCode int, opts RespondOptions) bool {
+	etag := opts.etag
+	if opts.computeETag {
+		etag = fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+		if opts.weakETag {
+			etag = "W/" + etag
+		}
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	if !opts.lastModified.IsZero() {
+		w.Header().Set("Last-Modified", opts.lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r == nil || statusCode != http.StatusOK || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+
+	// If-Modified-Since is ignored when If-None-Match is present.
+	// https://www.rfc-editor.org/rfc/rfc9110#section-13.2.2
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etag == "" {
+			return false
+		}
+
+		for _, tag := range splitETags(ifNoneMatch) {
+			if tag == "*" || strings.TrimPrefix(stripCoding(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if opts.lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !opts.lastModified.Truncate(time.Second).After(since)
+}
+
+func splitETags(header string) []string {
+	var tags []string
+	for tag := range strings.SplitSeq(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+
+	if tag, ok := strings.CutPrefix(etag, "W/"); ok {
+		return `W/"` + tag + `"`
+	}
+
+	return `"` + etag + `"`
+}
+
+// stripCoding removes the content coding added to the ETag by EncodedETag.
+func stripCoding(etag string) string {
+	tag, ok := strings.CutSuffix(etag, `"`)
+	if !ok {
+		return etag
+	}
+
+	for _, coding := range contentCodings {
+		if t, ok := strings.CutSuffix(tag, "-"+coding); ok {
+			return t + `"`
+		}
+	}
+
+	return etag
+}
+
+func isWeak(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
@@ -0,0 +1,102 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestConditional(t *testing.T) {
+	var handlerErr error
+	capture := func(next web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			handlerErr = next(ctx, w, r)
+			return nil
+		}
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, capture)
+
+	app.HandleFunc("GET /users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, user{ID: r.PathValue("id")}, http.StatusOK, web.WithETag("v2"))
+	})
+
+	app.HandleFunc("PUT /users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		if err := web.CheckIfMatch(ctx, "v2"); err != nil {
+			return err
+		}
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	})
+
+	t.Run("not-modified", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("If-None-Match", `W/"v1", "v2"`)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotModified {
+			t.Fatalf("Should receive a %d status code, got %d", http.StatusNotModified, w.Code)
+		}
+
+		if w.Body.Len() != 0 {
+			t.Errorf("Should receive an empty body, got %q", w.Body.String())
+		}
+
+		if got := w.Header().Get("ETag"); got != `"v2"` {
+			t.Errorf("Should receive the ETag, got %q", got)
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("If-None-Match", `"v1"`)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("Should receive the representation, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("if-match", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/users/42", nil)
+		r.Header.Set("If-Match", `"v1"`)
+		app.ServeHTTP(httptest.NewRecorder(), r)
+
+		if !errors.Is(handlerErr, web.ErrPreconditionFailed) {
+			t.Fatalf("Should fail the precondition, got %v", handlerErr)
+		}
+
+		r = httptest.NewRequest(http.MethodPut, "/users/42", nil)
+		r.Header.Set("If-Match", `"v2"`)
+		app.ServeHTTP(httptest.NewRecorder(), r)
+
+		if handlerErr != nil {
+			t.Fatalf("Should match the current ETag, got %v", handlerErr)
+		}
+	})
+
+	t.Run("encoded", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/users/42", nil)
+		r.Header.Set("If-Match", web.EncodedETag(`"v2"`, "gzip"))
+		app.ServeHTTP(httptest.NewRecorder(), r)
+
+		if handlerErr != nil {
+			t.Fatalf("Should match the ETag of the compressed representation, got %v", handlerErr)
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("If-None-Match", `"v2-gzip"`)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotModified {
+			t.Fatalf("Should receive a %d status code, got %d", http.StatusNotModified, w.Code)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"context"
+	"net/http"
 	"time"
 )
 
@@ -17,6 +18,18 @@ type Values struct {
 	TraceID    string
 	Now        time.Time
 	StatusCode int
+
+	// request is kept so the response helpers can honor the request
+	// headers, like Accept, without changing their signature.
+	request *http.Request
+
+	// problemDetails reports if the app responds errors using Problem
+	// Details.
+	problemDetails bool
+
+	// streaming reports if the handler started a stream, so the response
+	// headers are already written.
+	streaming bool
 }
 
 // GetValues returns the Values struct from the context.
@@ -59,6 +72,17 @@ func setStatusCode(ctx context.Context, statusCode int) {
 	v.StatusCode = statusCode
 }
 
+// getRequest returns the request being handled or nil if the context is
+// not bound to a request.
+func getRequest(ctx context.Context) *http.Request {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return nil
+	}
+
+	return v.request
+}
+
 func setValues(ctx context.Context, v *Values) context.Context {
 	return context.WithValue(ctx, key, v)
 }
@@ -0,0 +1,321 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	jsonv2 "github.com/go-json-experiment/json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Set of errors returned when the media type of a request or a response
+// is not supported by the registered encoders and decoders.
+var (
+	ErrNotAcceptable        = errors.New("not acceptable")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Encoder defines the behavior required to write a value into a response
+// body for a specific media type.
+type Encoder interface {
+	ContentType() string
+	CanEncode(v any) bool
+	Encode(v any) ([]byte, error)
+}
+
+// Decoder defines the behavior required to read a request body for a
+// specific media type into a value.
+type Decoder interface {
+	ContentType() string
+	CanDecode(v any) bool
+	Decode(r io.Reader, v any, opts DecodeOptions) error
+}
+
+// DecodeOptions represents the options that can be set for a single call
+// to Decode.
+type DecodeOptions struct {
+	// DisallowUnknownFields rejects a body with fields that don't exist in
+	// the destination value.
+	DisallowUnknownFields bool
+}
+
+// registry holds the set of encoders and decoders used by Respond and
+// Decode. The order of registration defines the preference used when the
+// client accepts more than one media type with the same quality.
+var registry = struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+	decoders []Decoder
+}{
+	encoders: []Encoder{jsonEncoder{}, protoEncoder{}},
+	decoders: []Decoder{jsonDecoder{}, protoDecoder{}},
+}
+
+// RegisterEncoder adds an encoder to the registry. An encoder registered
+// for a media type that already exists replaces the existing one.
+func RegisterEncoder(enc Encoder) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	i := slices.IndexFunc(registry.encoders, func(e Encoder) bool { return e.ContentType() == enc.ContentType() })
+	if i >= 0 {
+		registry.encoders[i] = enc
+		return
+	}
+
+	registry.encoders = append(registry.encoders, enc)
+}
+
+// RegisterDecoder adds a decoder to the registry. A decoder registered
+// for a media type that already exists replaces the existing one.
+func RegisterDecoder(dec Decoder) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	i := slices.IndexFunc(registry.decoders, func(d Decoder) bool { return d.ContentType() == dec.ContentType() })
+	if i >= 0 {
+		registry.decoders[i] = dec
+		return
+	}
+
+	registry.decoders = append(registry.decoders, dec)
+}
+
+// negotiateEncoder selects the encoder for the value based on the Accept
+// header. An empty header means the client accepts anything.
+func negotiateEncoder(accept string, v any) (Encoder, error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	ranges := parseAccept(accept)
+
+	var best Encoder
+	var bestQ float64
+
+	for _, enc := range registry.encoders {
+		if !enc.CanEncode(v) {
+			continue
+		}
+
+		if q := quality(ranges, enc.ContentType()); q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+	}
+
+	return best, nil
+}
+
+// defaultEncoder returns the first registered encoder that can encode the
+// value. It's used when a response must be written regardless of what the
+// client accepts.
+func defaultEncoder(v any) Encoder {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	for _, enc := range registry.encoders {
+		if enc.CanEncode(v) {
+			return enc
+		}
+	}
+
+	return jsonEncoder{}
+}
+
+// negotiateDecoder selects the decoder for the value based on the
+// Content-Type header. An empty header is treated as JSON.
+func negotiateDecoder(contentType string, v any) (Decoder, error) {
+	mediaType := "application/json"
+
+	if contentType != "" {
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+		}
+		mediaType = mt
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	for _, dec := range registry.decoders {
+		if dec.ContentType() == mediaType && dec.CanDecode(v) {
+			return dec, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+}
+
+// =============================================================================
+
+// mediaRange represents one of the media ranges of an Accept header.
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept parses the Accept header into its media ranges. Invalid
+// ranges are ignored. An empty header is equivalent to */*.
+func parseAccept(accept string) []mediaRange {
+	if strings.TrimSpace(accept) == "" {
+		return []mediaRange{{typ: "*", subtype: "*", q: 1}}
+	}
+
+	var ranges []mediaRange
+	for item := range strings.SplitSeq(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		typ, subtype, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	return ranges
+}
+
+// quality returns the quality the client assigned to the media type. The
+// most specific media range that matches the media type wins.
+func quality(ranges []mediaRange, contentType string) float64 {
+	typ, subtype, _ := strings.Cut(contentType, "/")
+
+	q := 0.0
+	specificity := -1
+
+	for _, mr := range ranges {
+		var s int
+		switch {
+		case mr.typ == typ && mr.subtype == subtype:
+			s = 2
+		case mr.typ == typ && mr.subtype == "*":
+			s = 1
+		case mr.typ == "*" && mr.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			q, specificity = mr.q, s
+		}
+	}
+
+	return q
+}
+
+// =============================================================================
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (jsonEncoder) CanEncode(v any) bool {
+	return true
+}
+
+// Encode encodes the protobuf messages with protojson, since their fields
+// and well known types have a JSON mapping of their own.
+func (jsonEncoder) Encode(v any) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return protojson.Marshal(msg)
+	}
+
+	return json.Marshal(v)
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) ContentType() string {
+	return "application/json"
+}
+
+func (jsonDecoder) CanDecode(v any) bool {
+	return true
+}
+
+// Decode decodes the protobuf messages with protojson, like Encode.
+func (jsonDecoder) Decode(r io.Reader, v any, opts DecodeOptions) error {
+	if msg, ok := v.(proto.Message); ok {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return protojson.UnmarshalOptions{DiscardUnknown: !opts.DisallowUnknownFields}.Unmarshal(b, msg)
+	}
+
+	return jsonv2.UnmarshalRead(r, v, jsonv2.RejectUnknownMembers(opts.DisallowUnknownFields))
+}
+
+// =============================================================================
+
+type protoEncoder struct{}
+
+func (protoEncoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protoEncoder) CanEncode(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (protoEncoder) Encode(v any) ([]byte, error) {
+	return proto.Marshal(v.(proto.Message))
+}
+
+type protoDecoder struct{}
+
+func (protoDecoder) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protoDecoder) CanDecode(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (protoDecoder) Decode(r io.Reader, v any, opts DecodeOptions) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	msg := v.(proto.Message)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return err
+	}
+
+	if opts.DisallowUnknownFields && len(msg.ProtoReflect().GetUnknown()) > 0 {
+		return errors.New("unknown fields in message")
+	}
+
+	return nil
+}
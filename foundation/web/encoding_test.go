@@ -0,0 +1,122 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/web"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRespondNegotiation(t *testing.T) {
+	ts := timestamppb.New(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	pb, err := proto.Marshal(ts)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the message: %s", err)
+	}
+
+	tests := []struct {
+		name        string
+		accept      string
+		data        any
+		status      int
+		err         error
+		contentType string
+		body        string
+	}{
+		{name: "default", data: user{ID: "1"}, status: http.StatusOK, contentType: "application/json", body: `{"id":"1","name":"","dryRun":false}`},
+		{name: "protojson", accept: "application/json", data: ts, status: http.StatusOK, contentType: "application/json", body: `"2024-05-01T10:00:00Z"`},
+		{name: "protobuf", accept: "application/json;q=0.5, application/x-protobuf", data: ts, status: http.StatusOK, contentType: "application/x-protobuf", body: string(pb)},
+		{name: "quality", accept: "application/x-protobuf;q=0, */*", data: ts, status: http.StatusOK, contentType: "application/json", body: `"2024-05-01T10:00:00Z"`},
+		{name: "not-acceptable", accept: "text/html", data: user{ID: "1"}, status: http.StatusOK, err: web.ErrNotAcceptable},
+		{name: "error-fallback", accept: "text/html", data: user{ID: "1"}, status: http.StatusBadRequest, contentType: "application/json", body: `{"id":"1","name":"","dryRun":false}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)})
+
+			var handlerErr error
+			app.HandleFunc("GET /data", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				handlerErr = web.Respond(ctx, w, tt.data, tt.status)
+				return nil
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/data", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if tt.err != nil {
+				if !errors.Is(handlerErr, tt.err) {
+					t.Fatalf("Should fail with %v, got %v", tt.err, handlerErr)
+				}
+				return
+			}
+
+			if handlerErr != nil {
+				t.Fatalf("Should be able to respond: %s", handlerErr)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Should respond with %s, got %s", tt.contentType, got)
+			}
+
+			if got := strings.TrimSpace(w.Body.String()); got != tt.body {
+				t.Errorf("Should receive the encoded body:\ngot: %q\nexp: %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestDecodeNegotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		err         error
+	}{
+		{name: "default", body: `"2024-05-01T10:00:00Z"`},
+		{name: "json", contentType: "application/json; charset=utf-8", body: `"2024-05-01T10:00:00Z"`},
+		{name: "unsupported", contentType: "text/plain", body: `"2024-05-01T10:00:00Z"`, err: web.ErrUnsupportedMediaType},
+		{name: "malformed", contentType: "application/", body: `"2024-05-01T10:00:00Z"`, err: web.ErrUnsupportedMediaType},
+		{name: "invalid", contentType: "application/json", body: `{"seconds":1}`, err: web.ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/data", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var ts timestamppb.Timestamp
+			err := web.Decode(r, &ts)
+
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Should fail with %v, got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Should be able to decode: %s", err)
+			}
+
+			if got := ts.AsTime(); !got.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("Should decode the message with protojson, got %v", got)
+			}
+		})
+	}
+}
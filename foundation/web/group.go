@@ -0,0 +1,137 @@
+package web
+
+import (
+	"maps"
+	"path"
+	"strings"
+)
+
+// Group represents a set of routes that share a path prefix and a set of
+// middleware. Groups can be nested, so a /v1 tree can be mounted with its own
+// authentication middleware and still add more specific sub trees.
+type Group struct {
+	app         *App
+	prefix      string
+	mw          []MidHandler
+	annotations map[string]string
+}
+
+// Group creates a new route group bound to the app. The group middleware is
+// executed after the app middleware and before any route specific middleware.
+func (a *App) Group(prefix string, mw ...MidHandler) *Group {
+	return &Group{
+		app:    a,
+		prefix: cleanPrefix(prefix),
+		mw:     mw,
+	}
+}
+
+// Group creates a nested route group. The prefix is appended to the parent
+// prefix and the middleware is executed after the parent group middleware.
+func (g *Group) Group(prefix string, mw ...MidHandler) *Group {
+
+	// Copy the parent middleware so sibling groups do not share the same
+	// backing array.
+	groupMw := make([]MidHandler, 0, len(g.mw)+len(mw))
+	groupMw = append(groupMw, g.mw...)
+	groupMw = append(groupMw, mw...)
+
+	return &Group{
+		app:         g.app,
+		prefix:      g.prefix + cleanPrefix(prefix),
+		mw:          groupMw,
+		annotations: g.annotations,
+	}
+}
+
+// Annotate returns a copy of the group whose routes carry the key/value
+// pair, so the routes document what the group enforces, like the
+// authorization rule of its middleware.
+//
+//	admin := app.Group("/admin", mid.Authorize(a, rule)).Annotate("auth.rule", rule)
+func (g *Group) Annotate(key string, value string) *Group {
+	annotations := maps.Clone(g.annotations)
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+
+	return &Group{
+		app:         g.app,
+		prefix:      g.prefix,
+		mw:          g.mw,
+		annotations: annotations,
+	}
+}
+
+// HandleFunc sets a handler function for a given HTTP method and path pair
+// relative to the group prefix.
+func (g *Group) HandleFunc(pattern string, handler Handler, mw ...MidHandler) {
+	g.handle(pattern, Endpoint{Handler: handler}, mw)
+}
+
+// HandleEndpoint sets an endpoint, like one produced by JSON, for a given
+// HTTP method and path pair relative to the group prefix.
+func (g *Group) HandleEndpoint(pattern string, e Endpoint, mw ...MidHandler) {
+	g.handle(pattern, e, mw)
+}
+
+// HandleWebSocket sets a websocket handler for a given HTTP method and path
+// pair relative to the group prefix. The upgrade request goes through the
+// group middleware, so authentication is applied before the upgrade.
+func (g *Group) HandleWebSocket(pattern string, handler WebSocketHandler, mw ...MidHandler) {
+	g.HandleFunc(pattern, g.app.webSocketHandler(handler), mw...)
+}
+
+func (g *Group) handle(pattern string, e Endpoint, mw []MidHandler) {
+
+	// group middleware first, route middleware afterwards
+	routeMw := make([]MidHandler, 0, len(g.mw)+len(mw))
+	routeMw = append(routeMw, g.mw...)
+	routeMw = append(routeMw, mw...)
+
+	g.app.handle(g.pattern(pattern), e, routeMw, g.annotations)
+}
+
+// HandleFuncNoMiddleware sets a handler function relative to the group
+// prefix that does not execute the app or group middleware.
+func (g *Group) HandleFuncNoMiddleware(pattern string, handler Handler) {
+	g.app.HandleFuncNoMiddleware(g.pattern(pattern), handler)
+}
+
+// pattern prepends the group prefix to the path of a ServeMux pattern while
+// keeping the optional method in front. A path without the leading slash gets
+// one, so "users" doesn't run into the prefix.
+// Example: "GET /users/{id}" with prefix "/v1" becomes "GET /v1/users/{id}".
+func (g *Group) pattern(pattern string) string {
+	method, p, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, p = "", pattern
+	}
+
+	p = strings.TrimLeft(p, " ")
+	if p != "" && !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	full := g.prefix + p
+	if full == "" {
+		full = "/"
+	}
+
+	if method == "" {
+		return full
+	}
+
+	return method + " " + full
+}
+
+// cleanPrefix normalizes a group prefix so it always starts with a slash
+// and never ends with one. The root prefix is represented as an empty string.
+func cleanPrefix(prefix string) string {
+	if prefix == "" || prefix == "/" {
+		return ""
+	}
+
+	return strings.TrimSuffix(path.Clean("/"+prefix), "/")
+}
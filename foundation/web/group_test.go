@@ -0,0 +1,96 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestGroup(t *testing.T) {
+	var calls []string
+
+	record := func(name string) web.MidHandler {
+		return func(next web.Handler) web.Handler {
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				calls = append(calls, name)
+				return next(ctx, w, r)
+			}
+		}
+	}
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls = append(calls, "handler")
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, record("app"))
+
+	v1 := app.Group("/v1", record("v1"))
+	v1.HandleFunc("GET /users/{id}", handler, record("route"))
+
+	admin := v1.Group("admin/", record("admin"))
+	admin.HandleFunc("GET /users", handler)
+
+	v1.HandleFunc("GET orders", handler)
+	v1.HandleFunc("products", handler)
+
+	testCases := []struct {
+		name   string
+		target string
+		calls  []string
+	}{
+		{name: "group", target: "/v1/users/1", calls: []string{"app", "v1", "route", "handler"}},
+		{name: "nested", target: "/v1/admin/users", calls: []string{"app", "v1", "admin", "handler"}},
+		{name: "no-slash", target: "/v1/orders", calls: []string{"app", "v1", "handler"}},
+		{name: "no-slash-no-method", target: "/v1/products", calls: []string{"app", "v1", "handler"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls = nil
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("Should receive a %d status code, got %d", http.StatusNoContent, w.Code)
+			}
+
+			if !slices.Equal(calls, tc.calls) {
+				t.Errorf("Should execute the middleware in order:\ngot: %v\nexp: %v", calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestGroupAnnotate(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)})
+
+	v1 := app.Group("/v1")
+	v1.HandleFunc("GET /status", handler)
+
+	admin := v1.Group("/admin").Annotate("auth.rule", "admin")
+	admin.Group("/users").HandleFunc("GET /{id}", handler)
+
+	routes := app.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("Should record 2 routes, got %d", len(routes))
+	}
+
+	if routes[0].Annotations != nil {
+		t.Errorf("Should not annotate the routes outside the group, got %v", routes[0].Annotations)
+	}
+
+	if got := routes[1].Annotations["auth.rule"]; got != "admin" {
+		t.Errorf("Should annotate the routes of the nested groups, got %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// EnableCORS binds a handler for the preflight OPTIONS requests of every
+// path registered so far, so it must be called once all the routes are
+// bound. Paths that already have an OPTIONS route are left alone. The
+// preflight requests go through the app middleware, which is expected to
+// apply the cross-origin policy, and the provided middleware.
+func (a *App) EnableCORS(mw ...MidHandler) {
+	methods := make(map[string][]string)
+	var paths []string
+
+	for _, rt := range a.Routes() {
+		if rt.Method == "" {
+			continue
+		}
+
+		if _, exists := methods[rt.Pattern]; !exists {
+			paths = append(paths, rt.Pattern)
+		}
+
+		methods[rt.Pattern] = append(methods[rt.Pattern], rt.Method)
+	}
+
+	for _, path := range paths {
+		if slices.Contains(methods[path], http.MethodOptions) {
+			continue
+		}
+
+		allow := strings.Join(append(methods[path], http.MethodOptions), ", ")
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("Allow", allow)
+			return Respond(ctx, w, nil, http.StatusNoContent)
+		}
+
+		handler := wrapMiddleware(mw, h)
+		handler = wrapMiddleware(a.mw, handler)
+
+		a.ServeMux.HandleFunc(http.MethodOptions+" "+path, a.generateHandlerFunc(handler))
+	}
+}
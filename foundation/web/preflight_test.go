@@ -0,0 +1,69 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestEnableCORS(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	custom := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("X-Custom", "true")
+		return web.Respond(ctx, w, nil, http.StatusOK)
+	}
+
+	var calls int
+	mw := func(next web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			calls++
+			return next(ctx, w, r)
+		}
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, mw)
+	app.HandleFunc("GET /users", handler)
+	app.HandleFunc("POST /users", handler)
+	app.HandleFunc("GET /orders", handler)
+	app.HandleFunc("OPTIONS /orders", custom)
+	app.EnableCORS()
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		allow  string
+	}{
+		{name: "bound", path: "/users", status: http.StatusNoContent, allow: "GET, POST, OPTIONS"},
+		{name: "existing", path: "/orders", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+
+			r := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Should receive a %d status code, got %d", tt.status, w.Code)
+			}
+
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Should allow %q, got %q", tt.allow, got)
+			}
+
+			if calls != 1 {
+				t.Errorf("Should run the app middleware once, got %d", calls)
+			}
+		})
+	}
+}
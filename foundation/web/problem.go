@@ -0,0 +1,103 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+)
+
+// ProblemContentType is the media type of a Problem Details document.
+const ProblemContentType = "application/problem+json"
+
+// Problem represents an RFC 9457 Problem Details document.
+// https://www.rfc-editor.org/rfc/rfc9457
+type Problem struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	TraceID  string
+
+	// Extensions holds the extension members of the problem, which are
+	// written at the top level of the document.
+	Extensions map[string]any
+}
+
+// MarshalJSON implements the json.Marshaler interface, writing the
+// extension members next to the standard members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+6)
+	maps.Copy(m, p.Extensions)
+
+	typ := p.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+
+	m["type"] = typ
+	m["title"] = p.Title
+	m["status"] = p.Status
+
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+
+	if p.TraceID != "" {
+		m["traceId"] = p.TraceID
+	}
+
+	return json.Marshal(m)
+}
+
+// UseProblemDetails reports if the app handling the request was configured
+// to respond errors using Problem Details.
+func UseProblemDetails(ctx context.Context) bool {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return false
+	}
+
+	return v.problemDetails
+}
+
+// RespondProblem writes the problem to the response writer. The title
+// defaults to the text of the status code, and the instance and trace id
+// default to the path and the trace id of the request.
+func RespondProblem(ctx context.Context, w http.ResponseWriter, p Problem) error {
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	if p.Instance == "" {
+		if r := getRequest(ctx); r != nil {
+			p.Instance = r.URL.Path
+		}
+	}
+
+	if p.TraceID == "" {
+		p.TraceID = GetTraceID(ctx)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("problem: encoding: %w", err)
+	}
+
+	setStatusCode(ctx, p.Status)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+
+	return nil
+}
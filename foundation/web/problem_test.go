@@ -0,0 +1,111 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestRespondProblem(t *testing.T) {
+	tests := []struct {
+		name    string
+		problem web.Problem
+		exp     map[string]any
+	}{
+		{
+			name:    "defaults",
+			problem: web.Problem{Status: http.StatusNotFound},
+			exp: map[string]any{
+				"type":     "about:blank",
+				"title":    "Not Found",
+				"status":   float64(http.StatusNotFound),
+				"instance": "/users/1",
+				"traceId":  "req-42",
+			},
+		},
+		{
+			name: "members",
+			problem: web.Problem{
+				Type:     "https://example.com/problems/invalid",
+				Title:    "Invalid user",
+				Status:   http.StatusBadRequest,
+				Detail:   "name: is required",
+				Instance: "/problems/1",
+				TraceID:  "trace-1",
+				Extensions: map[string]any{
+					"code":   "invalid_argument",
+					"fields": map[string]string{"name": "is required"},
+				},
+			},
+			exp: map[string]any{
+				"type":     "https://example.com/problems/invalid",
+				"title":    "Invalid user",
+				"status":   float64(http.StatusBadRequest),
+				"detail":   "name: is required",
+				"instance": "/problems/1",
+				"traceId":  "trace-1",
+				"code":     "invalid_argument",
+				"fields":   map[string]any{"name": "is required"},
+			},
+		},
+		{
+			name: "standard-members-win",
+			problem: web.Problem{
+				Status:     http.StatusConflict,
+				Extensions: map[string]any{"status": 200, "title": "OK"},
+			},
+			exp: map[string]any{
+				"type":     "about:blank",
+				"title":    "Conflict",
+				"status":   float64(http.StatusConflict),
+				"instance": "/users/1",
+				"traceId":  "req-42",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1), ProblemDetails: true})
+
+			var useProblem bool
+			app.HandleFunc("GET /users/{id}", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				useProblem = web.UseProblemDetails(ctx)
+				return web.RespondProblem(ctx, w, tt.problem)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			r.Header.Set("Accept", "application/json")
+			r.Header.Set(web.RequestIDHeader, "req-42")
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			if !useProblem {
+				t.Error("Should report the app uses Problem Details")
+			}
+
+			if w.Code != tt.problem.Status {
+				t.Errorf("Should receive a %d status code, got %d", tt.problem.Status, w.Code)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != web.ProblemContentType {
+				t.Errorf("Should respond with %s, got %s", web.ProblemContentType, got)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Should be able to decode the problem: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("Should respond the problem:\ngot: %v\nexp: %v", got, tt.exp)
+			}
+		})
+	}
+}
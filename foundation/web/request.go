@@ -1,12 +1,17 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
-	"github.com/go-json-experiment/json"
+	"github.com/zucchini/services-golang/foundation/validate"
 )
 
+// ErrInvalidPayload is returned when the body of the request can't be
+// decoded into the destination value.
+var ErrInvalidPayload = errors.New("unable to decode payload")
+
 // Param returns the web call parameters from the requests
 func Param(r *http.Request, name string) string {
 	return r.PathValue(name)
@@ -16,12 +21,53 @@ type validator interface {
 	Validate() error
 }
 
-// Decode reads the body of an HTTP request looking for a JSON document.
-// The body is decoded into the provided value.
-// If the value implements a validate function, it is executed.
-func Decode(r *http.Request, val any) error {
-	if err := json.UnmarshalRead(r.Body, val, json.RejectUnknownMembers(false)); err != nil {
-		return fmt.Errorf("unable to decode payload: %v", err)
+// DisallowUnknownFields makes Decode reject a body with fields that don't
+// exist in the destination value.
+func DisallowUnknownFields() func(opts *DecodeOptions) {
+	return func(opts *DecodeOptions) {
+		opts.DisallowUnknownFields = true
+	}
+}
+
+// Decode reads the body of an HTTP request. The decoder is selected from the
+// registry using the Content-Type header of the request, JSON being the
+// default. The body is decoded into the provided value and validated using
+// the validate tags of its fields. Every failing field is reported in a
+// validate.FieldErrors error. If the value implements a validate function,
+// it is executed after the tags are checked.
+func Decode(r *http.Request, val any, options ...func(opts *DecodeOptions)) error {
+	if err := decodeBody(r, val, options...); err != nil {
+		return err
+	}
+
+	return check(val)
+}
+
+// decodeBody decodes the body of the request into the value without
+// validating it.
+func decodeBody(r *http.Request, val any, options ...func(opts *DecodeOptions)) error {
+	var opts DecodeOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	dec, err := negotiateDecoder(r.Header.Get("Content-Type"), val)
+	if err != nil {
+		return err
+	}
+
+	if err := dec.Decode(r.Body, val, opts); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	return nil
+}
+
+// check validates the value using the validate tags of its fields and its
+// validate function, if it has one.
+func check(val any) error {
+	if err := validate.Check(val); err != nil {
+		return err
 	}
 
 	if v, ok := val.(validator); ok {
@@ -2,29 +2,59 @@ package web
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 )
 
 // Respond is a helper function that writes data to the response writer.
-// We do not need to use json around the project, we can easily change to protobuf or any other format
-// by changing the implementation of this function.
-func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int) error {
-	setStatusCode(ctx, statusCode)
+// The encoder is selected from the registry using the Accept header of the
+// request, JSON being the default. Error responses always reach the client
+// using the default encoder when the client does not accept any of the
+// registered media types. The options set the validators of the response,
+// which are used to answer a conditional GET with a 304.
+func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int, options ...func(opts *RespondOptions)) error {
+	var opts RespondOptions
+	for _, option := range options {
+		option(&opts)
+	}
 
 	if statusCode == http.StatusNoContent {
+		setStatusCode(ctx, statusCode)
 		w.WriteHeader(statusCode)
 		return nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+	r := getRequest(ctx)
+
+	var accept string
+	if r != nil {
+		accept = r.Header.Get("Accept")
+	}
+
+	enc, err := negotiateEncoder(accept, data)
+	if err != nil {
+		if statusCode < http.StatusBadRequest {
+			return err
+		}
+
+		enc = defaultEncoder(data)
+	}
 
-	b, err := json.Marshal(data)
+	b, err := enc.Encode(data)
 	if err != nil {
 		return err
 	}
 
+	if conditional(r, w, b, statusCode, opts) {
+		setStatusCode(ctx, http.StatusNotModified)
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	setStatusCode(ctx, statusCode)
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.WriteHeader(statusCode)
+
 	if _, err := w.Write(b); err != nil {
 		return err
 	}
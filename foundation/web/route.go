@@ -0,0 +1,93 @@
+package web
+
+import (
+	"maps"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Route describes a route registered in the app. It's used to document the
+// API, for example to generate a schema of the service.
+type Route struct {
+	Method      string
+	Pattern     string
+	Status      int
+	Request     reflect.Type
+	Response    reflect.Type
+	Middleware  []string
+	Annotations map[string]string
+}
+
+// routes keeps the table of the routes registered in the app.
+type routes struct {
+	mu    sync.RWMutex
+	table []Route
+}
+
+// Routes returns the table of the routes registered in the app in the order
+// they were registered.
+func (a *App) Routes() []Route {
+	a.routes.mu.RLock()
+	defer a.routes.mu.RUnlock()
+
+	return slices.Clone(a.routes.table)
+}
+
+// addRoute records the route for the pattern, with what the endpoint and
+// the group document about it. The middleware is the full chain executed for
+// the route, outermost first.
+func (a *App) addRoute(pattern string, e Endpoint, mw []MidHandler, annotations map[string]string) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+
+	rt := Route{
+		Method:   method,
+		Pattern:  strings.TrimLeft(path, " "),
+		Status:   e.Status,
+		Request:  e.Request,
+		Response: e.Response,
+	}
+
+	if len(annotations) > 0 {
+		rt.Annotations = maps.Clone(annotations)
+	}
+
+	for _, m := range mw {
+		if m != nil {
+			rt.Middleware = append(rt.Middleware, funcName(m))
+		}
+	}
+
+	a.routes.mu.Lock()
+	defer a.routes.mu.Unlock()
+
+	a.routes.table = append(a.routes.table, rt)
+}
+
+// =============================================================================
+
+// funcSuffix matches the suffixes the compiler adds to the names of closures
+// and method values.
+var funcSuffix = regexp.MustCompile(`(\.func\d+)+$|-fm$`)
+
+// funcName returns the short name of the function, like mid.Logger for the
+// closure returned by the Logger middleware.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return ""
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return funcSuffix.ReplaceAllString(name, "")
+}
@@ -0,0 +1,249 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamClosed is returned when an event is sent on a closed stream.
+var ErrStreamClosed = errors.New("stream closed")
+
+// Event represents a single Server-Sent Event.
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	ID    string
+	Name  string
+	Data  any
+	Retry time.Duration
+}
+
+// SSE represents a Server-Sent Events stream bound to a request. Events can
+// be sent from multiple goroutines. The stream is closed when the client
+// goes away or when Close is called.
+type SSE struct {
+	ctx         context.Context
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	heartbeat   time.Duration
+	lastEventID string
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// WithHeartbeat sets the interval used to send comments to the client so
+// proxies do not close an idle connection. A zero interval disables it.
+func WithHeartbeat(interval time.Duration) func(s *SSE) {
+	return func(s *SSE) {
+		s.heartbeat = interval
+	}
+}
+
+// NewSSE turns the response into a Server-Sent Events stream. The response
+// headers are written right away, so the handler must not call Respond
+// after the stream is created, and an error it returns is only logged by
+// the error middleware. The caller must call Close when done.
+func NewSSE(ctx context.Context, w http.ResponseWriter, options ...func(s *SSE)) (*SSE, error) {
+	s := SSE{
+		ctx:       ctx,
+		w:         w,
+		rc:        http.NewResponseController(w),
+		heartbeat: 15 * time.Second,
+		done:      make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(&s)
+	}
+
+	if r := getRequest(ctx); r != nil {
+		s.lastEventID = r.Header.Get("Last-Event-ID")
+	}
+
+	// Streams live longer than the server write timeout, so the deadline
+	// for this response is removed. Not every writer supports it.
+	if err := s.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, fmt.Errorf("sse: clearing write deadline: %w", err)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+
+	setStatusCode(ctx, http.StatusOK)
+	setStreaming(ctx)
+	w.WriteHeader(http.StatusOK)
+
+	if err := s.rc.Flush(); err != nil {
+		return nil, fmt.Errorf("sse: flushing: %w", err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.keepAlive()
+	}()
+
+	return &s, nil
+}
+
+// IsStreaming reports if the handler started a stream for the request. The
+// response headers are already written, so an error can't be responded.
+func IsStreaming(ctx context.Context) bool {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return false
+	}
+
+	return v.streaming
+}
+
+func setStreaming(ctx context.Context) {
+	v, ok := ctx.Value(key).(*Values)
+	if !ok {
+		return
+	}
+
+	v.streaming = true
+}
+
+// LastEventID returns the id of the last event the client received before
+// reconnecting, so the handler can resume the stream from that point.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel that is closed when the client goes away or when
+// the stream is closed.
+func (s *SSE) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send writes the event to the client and flushes it. Values that are not a
+// string or a byte slice are encoded as JSON.
+func (s *SSE) Send(ev Event) error {
+	var data string
+
+	switch v := ev.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("sse: encoding data: %w", err)
+		}
+		data = string(b)
+	}
+
+	var b strings.Builder
+
+	if ev.ID != "" {
+		b.WriteString("id: " + sanitizeField(ev.ID) + "\n")
+	}
+
+	if ev.Name != "" {
+		b.WriteString("event: " + sanitizeField(ev.Name) + "\n")
+	}
+
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	for line := range strings.SplitSeq(data, "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// Close stops the heartbeat and releases the stream. It's safe to call
+// Close more than once.
+func (s *SSE) Close() {
+	s.mu.Lock()
+	s.markClosed()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// keepAlive sends a comment on every heartbeat interval and closes the
+// stream when the client goes away or a heartbeat can't be written.
+func (s *SSE) keepAlive() {
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-s.ctx.Done():
+			s.mu.Lock()
+			s.markClosed()
+			s.mu.Unlock()
+			return
+
+		case <-tick:
+			if err := s.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// markClosed closes the stream, so Done is signaled and the events are no
+// longer sent. It must be called with the mutex held.
+func (s *SSE) markClosed() {
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+func (s *SSE) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+
+	// A failed write means the client is gone, so the stream is closed
+	// for the handler to stop.
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		s.markClosed()
+		return err
+	}
+
+	if err := s.rc.Flush(); err != nil {
+		s.markClosed()
+		return err
+	}
+
+	return nil
+}
+
+// sanitizeField removes the line breaks that would break the event format.
+func sanitizeField(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
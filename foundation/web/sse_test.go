@@ -0,0 +1,46 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+// brokenWriter fails the writes of the body, like a client that went away.
+type brokenWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w brokenWriter) Write(b []byte) (int, error) {
+	return 0, syscall.EPIPE
+}
+
+func TestSSEHeartbeatFailure(t *testing.T) {
+	w := brokenWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	s, err := web.NewSSE(context.Background(), w, web.WithHeartbeat(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Should be able to start the stream: %s", err)
+	}
+	defer s.Close()
+
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should close the stream when a heartbeat fails.")
+	}
+
+	if err := s.Send(web.Event{Data: "hello"}); !errors.Is(err, web.ErrStreamClosed) {
+		t.Errorf("Should not send on a closed stream, got %v", err)
+	}
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Should start the stream with a %d status code, got %d", http.StatusOK, w.Code)
+	}
+}
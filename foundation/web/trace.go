@@ -0,0 +1,156 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Set of headers used to carry the trace id between services.
+const (
+	// TraceParentHeader is the W3C Trace Context header.
+	// https://www.w3.org/TR/trace-context/#traceparent-header
+	TraceParentHeader = "traceparent"
+
+	// RequestIDHeader is used as a fallback when the caller does not speak
+	// W3C Trace Context. The trace id is also echoed back to the client in
+	// this header.
+	RequestIDHeader = "X-Request-ID"
+)
+
+// nilTraceID is the trace id of a context outside a request.
+var nilTraceID = uuid.Nil.String()
+
+// maxRequestIDLen limits the size of a request id we accept from the
+// outside world since it is going to be written in every log line.
+const maxRequestIDLen = 128
+
+// SetTraceHeaders adds the trace id stored in the context to the specified
+// headers so the next service can continue the same trace. Outside a request
+// the trace id is the nil uuid, which would log every background call under
+// the same trace, so no header is set and the next service starts its own.
+func SetTraceHeaders(ctx context.Context, h http.Header) {
+	traceID := GetTraceID(ctx)
+	if traceID == nilTraceID {
+		return
+	}
+
+	h.Set(RequestIDHeader, traceID)
+
+	if h.Get(TraceParentHeader) != "" {
+		return
+	}
+
+	// A traceparent can only be produced when the trace id is a valid
+	// 16 byte identifier.
+	id, err := uuid.Parse(traceID)
+	if err != nil {
+		return
+	}
+
+	// The parent is the active span when there is one, or else a random
+	// span id.
+	spanID := trace.SpanContextFromContext(ctx).SpanID()
+	if !spanID.IsValid() {
+		if _, err := rand.Read(spanID[:]); err != nil {
+			return
+		}
+	}
+
+	h.Set(TraceParentHeader, "00-"+hex.EncodeToString(id[:])+"-"+spanID.String()+"-01")
+}
+
+// traceIDFromRequest returns the trace id provided by the caller. It looks
+// at the X-Request-ID header first and then at the traceparent header. If
+// neither is present or valid, a new trace id is generated.
+//
+// The X-Request-ID header wins because SetTraceHeaders forwards the trace id
+// in it, while the traceparent of the same request can be the one injected
+// by the otel propagator, carrying the trace id of the span instead.
+func traceIDFromRequest(r *http.Request) string {
+	if requestID := r.Header.Get(RequestIDHeader); validRequestID(requestID) {
+		return requestID
+	}
+
+	if traceID, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+		return traceID
+	}
+
+	return uuid.NewString()
+}
+
+// hasTraceID reports if the caller supplied a valid trace id, through the
+// traceparent or the X-Request-ID header.
+func hasTraceID(r *http.Request) bool {
+	if _, ok := parseTraceParent(r.Header.Get(TraceParentHeader)); ok {
+		return true
+	}
+
+	return validRequestID(r.Header.Get(RequestIDHeader))
+}
+
+// parseTraceParent extracts the trace id from a W3C traceparent header and
+// returns it using the uuid format we use for trace ids across the logs.
+// Format: {version}-{trace-id}-{parent-id}-{trace-flags}
+// Example: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func parseTraceParent(traceParent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 {
+		return "", false
+	}
+
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version ff is forbidden and version 00 can't have more fields.
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", false
+	}
+
+	if len(traceID) != 32 || len(parentID) != 16 || len(flags) != 2 {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(version + parentID + flags); err != nil {
+		return "", false
+	}
+
+	b, err := hex.DecodeString(traceID)
+	if err != nil {
+		return "", false
+	}
+
+	id, err := uuid.FromBytes(b)
+	if err != nil || id == uuid.Nil {
+		return "", false
+	}
+
+	// The parent id of all zeroes is invalid.
+	if strings.Trim(parentID, "0") == "" {
+		return "", false
+	}
+
+	return id.String(), true
+}
+
+// validRequestID checks the request id is safe to be used as a trace id.
+func validRequestID(requestID string) bool {
+	if requestID == "" || requestID == nilTraceID || len(requestID) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zucchini/services-golang/foundation/web"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceID(t *testing.T) {
+	cfg := web.Config{
+		Shutdown: make(chan os.Signal, 1),
+		Tracer:   sdktrace.NewTracerProvider().Tracer("test"),
+	}
+
+	app := web.NewApp(cfg)
+
+	var traceID, spanTraceID string
+	app.HandleFunc("GET /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		traceID = web.GetTraceID(ctx)
+		spanTraceID = uuid.UUID(trace.SpanFromContext(ctx).SpanContext().TraceID()).String()
+		return nil
+	})
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		exp    string
+	}{
+		{
+			name: "generated",
+		},
+		{
+			name:   "request-id",
+			header: map[string]string{web.RequestIDHeader: "req-42"},
+			exp:    "req-42",
+		},
+		{
+			name: "traceparent",
+			header: map[string]string{
+				web.TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+			exp: "4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		},
+		{
+			name: "request-id-and-traceparent",
+			header: map[string]string{
+				web.TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				web.RequestIDHeader:   "req-42",
+			},
+			exp: "req-42",
+		},
+		{
+			name: "invalid-request-id",
+			header: map[string]string{
+				web.TraceParentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				web.RequestIDHeader:   "req 42",
+			},
+			exp: "4bf92f35-77b3-4da6-a3ce-929d0e0e4736",
+		},
+		{
+			name:   "nil-request-id",
+			header: map[string]string{web.RequestIDHeader: uuid.Nil.String()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, r)
+
+			// Without an id from the caller, the one of the span is used.
+			exp := tt.exp
+			if exp == "" {
+				exp = spanTraceID
+			}
+
+			if traceID != exp {
+				t.Errorf("Should use the trace id %s, got %s", exp, traceID)
+			}
+
+			if got := w.Header().Get(web.RequestIDHeader); got != exp {
+				t.Errorf("Should echo the trace id %s, got %s", exp, got)
+			}
+		})
+	}
+}
+
+func TestSetTraceHeaders(t *testing.T) {
+	t.Run("outside-request", func(t *testing.T) {
+		h := make(http.Header)
+		web.SetTraceHeaders(context.Background(), h)
+
+		if len(h) != 0 {
+			t.Errorf("Should not set the nil trace id, got %v", h)
+		}
+	})
+
+	t.Run("request", func(t *testing.T) {
+		cfg := web.Config{
+			Shutdown: make(chan os.Signal, 1),
+			Tracer:   sdktrace.NewTracerProvider().Tracer("test"),
+		}
+
+		app := web.NewApp(cfg)
+
+		var h http.Header
+		var spanID string
+		app.HandleFunc("GET /users", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			h = make(http.Header)
+			web.SetTraceHeaders(ctx, h)
+			spanID = trace.SpanFromContext(ctx).SpanContext().SpanID().String()
+			return nil
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.Header.Set(web.RequestIDHeader, "5cf37266-3473-4006-984f-9325122678b7")
+		app.ServeHTTP(httptest.NewRecorder(), r)
+
+		if got := h.Get(web.RequestIDHeader); got != "5cf37266-3473-4006-984f-9325122678b7" {
+			t.Errorf("Should forward the trace id, got %q", got)
+		}
+
+		exp := "00-5cf3726634734006984f9325122678b7-" + spanID + "-01"
+		if got := h.Get(web.TraceParentHeader); got != exp {
+			t.Errorf("Should use the active span as the parent:\ngot: %s\nexp: %s", got, exp)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// TypedOptions represents the options that can be set for a typed handler.
+type TypedOptions struct {
+	status  int
+	decode  []func(opts *DecodeOptions)
+	respond []func(opts *RespondOptions)
+	noValue bool
+}
+
+// WithStatus sets the status code used when the handler succeeds. The
+// default is 200.
+func WithStatus(statusCode int) func(opts *TypedOptions) {
+	return func(opts *TypedOptions) {
+		opts.status = statusCode
+	}
+}
+
+// NoContent makes the handler respond with a 204 and no body when it
+// succeeds. The value returned by the function is ignored.
+func NoContent() func(opts *TypedOptions) {
+	return func(opts *TypedOptions) {
+		opts.status = http.StatusNoContent
+		opts.noValue = true
+	}
+}
+
+// WithDecodeOptions sets the options used to decode the request body.
+func WithDecodeOptions(options ...func(opts *DecodeOptions)) func(opts *TypedOptions) {
+	return func(opts *TypedOptions) {
+		opts.decode = append(opts.decode, options...)
+	}
+}
+
+// WithRespondOptions sets the options used to write the response, like
+// ComputeETag.
+func WithRespondOptions(options ...func(opts *RespondOptions)) func(opts *TypedOptions) {
+	return func(opts *TypedOptions) {
+		opts.respond = append(opts.respond, options...)
+	}
+}
+
+// Endpoint represents a handler along with what it documents about its
+// route, like the types of the request and the response. It's produced by
+// the typed adapters, like JSON, and bound with HandleEndpoint.
+type Endpoint struct {
+	Handler  Handler
+	Status   int
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// JSON adapts a typed function into an Endpoint. The request is built from the
+// body, the path parameters and the query string of the request, following
+// the rules of Decode, DecodePath and DecodeQuery, and validated once every
+// source is applied. The value returned by the function is written with
+// Respond. The request and response types are recorded in the route table
+// of the app.
+//
+//	app.HandleEndpoint("POST /users", web.JSON(api.create, web.WithStatus(http.StatusCreated)))
+func JSON[Req any, Resp any](fn func(ctx context.Context, req Req) (Resp, error), options ...func(opts *TypedOptions)) Endpoint {
+	opts := TypedOptions{
+		status: http.StatusOK,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	reqType := reflect.TypeFor[Req]()
+	respType := reflect.TypeFor[Resp]()
+	if opts.noValue {
+		respType = nil
+	}
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var req Req
+		if err := decodeRequest(r, &req, opts.decode); err != nil {
+			return err
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if opts.noValue {
+			return Respond(ctx, w, nil, http.StatusNoContent)
+		}
+
+		return Respond(ctx, w, resp, opts.status, opts.respond...)
+	}
+
+	e := Endpoint{
+		Handler:  h,
+		Status:   opts.status,
+		Request:  reqType,
+		Response: respType,
+	}
+
+	return e
+}
+
+// decodeRequest fills the value from every source of the request and then
+// validates it. The body is only decoded when the request has one.
+func decodeRequest(r *http.Request, val any, options []func(opts *DecodeOptions)) error {
+	if hasBody(r) {
+		if err := decodeBody(r, val, options...); err != nil {
+			return err
+		}
+	}
+
+	if reflect.TypeOf(val).Elem().Kind() == reflect.Struct {
+		if err := bind(val, "path", pathLookup(r)); err != nil {
+			return err
+		}
+
+		if err := bind(val, "query", queryLookup(r)); err != nil {
+			return err
+		}
+	}
+
+	return check(val)
+}
+
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
@@ -0,0 +1,88 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zucchini/services-golang/foundation/validate"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+type updateUser struct {
+	ID      string `path:"id"`
+	DryRun  bool   `query:"dryRun"`
+	Name    string `json:"name" validate:"required"`
+	Version int    `json:"version"`
+}
+
+type user struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	DryRun bool   `json:"dryRun"`
+}
+
+func TestJSON(t *testing.T) {
+	update := func(ctx context.Context, req updateUser) (user, error) {
+		return user{ID: req.ID, Name: req.Name, DryRun: req.DryRun}, nil
+	}
+
+	var handlerErr error
+	capture := func(next web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			handlerErr = next(ctx, w, r)
+			return nil
+		}
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)}, capture)
+	app.HandleEndpoint("PUT /users/{id}", web.JSON(update, web.WithStatus(http.StatusAccepted)))
+
+	t.Run("decode", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/users/42?dryRun=true", strings.NewReader(`{"name":"bill"}`))
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, r)
+
+		if handlerErr != nil {
+			t.Fatalf("Should be able to handle the request: %s", handlerErr)
+		}
+
+		if w.Code != http.StatusAccepted {
+			t.Errorf("Should receive a %d status code, got %d", http.StatusAccepted, w.Code)
+		}
+
+		exp := `{"id":"42","name":"bill","dryRun":true}`
+		if got := strings.TrimSpace(w.Body.String()); got != exp {
+			t.Errorf("Should receive the encoded response:\ngot: %s\nexp: %s", got, exp)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/users/42", strings.NewReader(`{"version":1}`))
+		app.ServeHTTP(httptest.NewRecorder(), r)
+
+		if !validate.IsFieldErrors(handlerErr) {
+			t.Fatalf("Should receive field errors, got %v", handlerErr)
+		}
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		routes := app.Routes()
+		if len(routes) != 1 {
+			t.Fatalf("Should record a single route, got %d", len(routes))
+		}
+
+		rt := routes[0]
+		if rt.Method != http.MethodPut || rt.Pattern != "/users/{id}" || rt.Status != http.StatusAccepted {
+			t.Errorf("Should record the route, got %+v", rt)
+		}
+
+		if rt.Request != reflect.TypeFor[updateUser]() || rt.Response != reflect.TypeFor[user]() {
+			t.Errorf("Should record the request and response types, got %v and %v", rt.Request, rt.Response)
+		}
+	})
+}
@@ -10,6 +10,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/zucchini/services-golang/foundation/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Handler is a function that can handle a http request within our small little own HTTP
@@ -23,28 +29,90 @@ type App struct {
 	// We embed http.ServeMux so we can use it as our router.
 	// My App is not everything that http.ServeMux is.
 	*http.ServeMux
-	shutdown chan os.Signal
-	mw       []MidHandler
+	shutdown       chan os.Signal
+	tracer         trace.Tracer
+	problemDetails bool
+	mw             []MidHandler
+	routes         routes
+	upgrader       websocket.Upgrader
+	sockets        sockets
+}
+
+// Config represents the configuration of the App.
+type Config struct {
+	// Shutdown receives the signal to shut down the app when an integrity
+	// issue is identified.
+	Shutdown chan os.Signal
+
+	// Tracer starts the server span of every request. A nil tracer disables
+	// tracing for the app.
+	Tracer trace.Tracer
+
+	// ProblemDetails makes the app report errors using RFC 9457 Problem
+	// Details. See UseProblemDetails.
+	ProblemDetails bool
 }
 
 // NewApp creates a new App value that contains the information for the HTTP server.
-func NewApp(shutdown chan os.Signal, mw ...MidHandler) *App {
+func NewApp(cfg Config, mw ...MidHandler) *App {
+	tracer := cfg.Tracer
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("")
+	}
+
 	return &App{
-		ServeMux: http.NewServeMux(),
-		shutdown: shutdown,
-		mw:       mw,
+		ServeMux:       http.NewServeMux(),
+		shutdown:       cfg.Shutdown,
+		tracer:         tracer,
+		problemDetails: cfg.ProblemDetails,
+		mw:             mw,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+		sockets: sockets{
+			conns: make(map[*websocket.Conn]context.CancelFunc),
+		},
 	}
 }
 
+// ProblemDetails reports if the app responds errors using Problem Details.
+func (a *App) ProblemDetails() bool {
+	return a.problemDetails
+}
+
 // SignalShutdown is used to gracefully Shutdown the app when an integrity issue is identified.
 func (a *App) SignalShutdown() {
 	a.shutdown <- syscall.SIGTERM
 }
 
+// HandleFunc sets a handler function for a given HTTP method and path pair
+// to the application server mux.
 func (a *App) HandleFunc(pattern string, handler Handler, mw ...MidHandler) {
+	a.handle(pattern, Endpoint{Handler: handler}, mw, nil)
+}
+
+// HandleEndpoint sets an endpoint, like one produced by JSON, for a given
+// HTTP method and path pair to the application server mux. What the endpoint
+// documents is recorded in the route table.
+func (a *App) HandleEndpoint(pattern string, e Endpoint, mw ...MidHandler) {
+	a.handle(pattern, e, mw, nil)
+}
+
+// handle records the route, wraps the route, group and general middleware
+// around the handler and binds it to the mux. The route and group middleware
+// is provided outermost first.
+func (a *App) handle(pattern string, e Endpoint, mw []MidHandler, annotations map[string]string) {
+	chain := make([]MidHandler, 0, len(a.mw)+len(mw))
+	chain = append(chain, a.mw...)
+	chain = append(chain, mw...)
+
+	a.addRoute(pattern, e, chain, annotations)
+
 	// local middleware first
 	// This allows us to for example, add an authentication middleware only to this handler.
-	handler = wrapMiddleware(mw, handler)
+	handler := wrapMiddleware(mw, e.Handler)
+
 	// general middleware afters
 	handler = wrapMiddleware(a.mw, handler)
 
@@ -54,6 +122,8 @@ func (a *App) HandleFunc(pattern string, handler Handler, mw ...MidHandler) {
 // HandleFuncNoMiddleware is a helper function that handles a http request
 // without any middleware.
 func (a *App) HandleFuncNoMiddleware(pattern string, handler Handler) {
+	a.addRoute(pattern, Endpoint{Handler: handler}, nil, nil)
+
 	a.ServeMux.HandleFunc(pattern, a.generateHandlerFunc(handler))
 }
 
@@ -65,12 +135,41 @@ func (a *App) generateHandlerFunc(handler Handler) http.HandlerFunc {
 		// Remember, this is a fundational layer. Highly portable.
 		// Do not log here or execute code specific to a handler.
 
+		ctx, span := a.startSpan(r)
+		defer span.End()
+
+		// Continue the trace started by the caller if there is one, so a
+		// request can be followed across services.
 		v := &Values{
-			TraceID: uuid.NewString(),
-			Now:     time.Now(),
+			TraceID:        traceIDFromRequest(r),
+			Now:            time.Now(),
+			request:        r,
+			problemDetails: a.problemDetails,
 		}
 
-		ctx := setValues(r.Context(), v)
+		// The trace id of the span is used unless the caller supplied its own,
+		// which is echoed back so the caller can find the logs. When the span
+		// doesn't continue it, the caller's id is added to the span to relate
+		// them.
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			spanTraceID := uuid.UUID(sc.TraceID()).String()
+
+			switch {
+			case !hasTraceID(r):
+				v.TraceID = spanTraceID
+
+			case spanTraceID != v.TraceID:
+				span.SetAttributes(attribute.String("request.id", v.TraceID))
+			}
+		}
+
+		ctx = setValues(ctx, v)
+
+		w.Header().Set(RequestIDHeader, v.TraceID)
+
+		defer func() {
+			span.SetAttributes(semconv.HTTPResponseStatusCode(v.StatusCode))
+		}()
 
 		if err := handler(ctx, w, r); err != nil {
 
@@ -93,6 +192,27 @@ func (a *App) generateHandlerFunc(handler Handler) http.HandlerFunc {
 	return h
 }
 
+// startSpan initializes the request by adding a server span and writing
+// the tracer into the context for the different layers to use.
+func (a *App) startSpan(r *http.Request) (context.Context, trace.Span) {
+	ctx := otel.ExtractTrace(r.Context(), r.Header)
+
+	ctx, span := a.tracer.Start(
+		ctx,
+		r.Pattern,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.HTTPRoute(r.Pattern),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+
+	ctx = otel.InjectTracing(ctx, a.tracer)
+
+	return ctx, span
+}
+
 // validateError validates the error for special conditions that do not
 // warrant an actual Shutdown by the system.
 func validateError(err error) bool {
@@ -132,6 +252,16 @@ func validateError(err error) bool {
 		// packet instead of the TCP FIN, which is used to close a connection under normal
 		// circumstances.
 		return false
+
+	case errors.Is(err, http.ErrHijacked):
+		// A websocket handler failed after the connection was upgraded, so
+		// the error response could not be written to the client.
+		return false
+
+	case errors.Is(err, ErrStreamClosed):
+		// The client went away while an event stream was still sending
+		// events. This is the normal way for a stream to end.
+		return false
 	}
 
 	return true
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketHandler is a function that handles an upgraded websocket
+// connection. The connection is closed by the framework once the handler
+// returns. The context is canceled when the app starts draining the open
+// connections during shutdown.
+//
+// No response can be written once the connection is upgraded, so an error
+// returned by the handler is wrapped with http.ErrHijacked and the error
+// middleware only logs it. Errors produced by the connection going away,
+// like a close frame from the client, are not errors and are dropped.
+type WebSocketHandler func(ctx context.Context, conn *websocket.Conn) error
+
+// sockets keeps track of the open websocket connections. Websocket
+// connections are hijacked from the http server, so they are not tracked
+// by http.Server.Shutdown.
+type sockets struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+	conns    map[*websocket.Conn]context.CancelFunc
+}
+
+// HandleWebSocket sets a websocket handler for a given HTTP method and path
+// pair. The upgrade request goes through the app middleware and the route
+// middleware like any other request, so authentication is applied before
+// the connection is upgraded.
+func (a *App) HandleWebSocket(pattern string, handler WebSocketHandler, mw ...MidHandler) {
+	a.HandleFunc(pattern, a.webSocketHandler(handler), mw...)
+}
+
+// ShutdownWebSockets asks every open websocket connection to close and waits
+// for their handlers to return. If the context expires first, the remaining
+// connections are closed by force.
+func (a *App) ShutdownWebSockets(ctx context.Context) error {
+	a.sockets.mu.Lock()
+	a.sockets.draining = true
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	deadline := time.Now().Add(time.Second)
+
+	for conn, cancel := range a.sockets.conns {
+		conn.WriteControl(websocket.CloseMessage, msg, deadline)
+		cancel()
+	}
+	a.sockets.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.sockets.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		a.sockets.mu.Lock()
+		for conn := range a.sockets.conns {
+			conn.Close()
+		}
+		a.sockets.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+func (a *App) webSocketHandler(handler WebSocketHandler) Handler {
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+
+		// The upgrader writes its own error response when the handshake
+		// fails, so we only need to record the status code.
+		upgrader := a.upgrader
+		upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			setStatusCode(ctx, status)
+			http.Error(w, http.StatusText(status), status)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		a.sockets.mu.Lock()
+		draining := a.sockets.draining
+		if !draining {
+			a.sockets.wg.Add(1)
+		}
+		a.sockets.mu.Unlock()
+
+		if draining {
+			return Respond(ctx, w, nil, http.StatusServiceUnavailable)
+		}
+		defer a.sockets.wg.Done()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return nil
+		}
+		defer conn.Close()
+
+		setStatusCode(ctx, http.StatusSwitchingProtocols)
+
+		// The app may have started draining during the upgrade, after it
+		// asked the registered connections to close, so the connection is
+		// closed right away instead of being registered.
+		a.sockets.mu.Lock()
+		draining = a.sockets.draining
+		if !draining {
+			a.sockets.conns[conn] = cancel
+		}
+		a.sockets.mu.Unlock()
+
+		if draining {
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			return nil
+		}
+
+		defer func() {
+			a.sockets.mu.Lock()
+			delete(a.sockets.conns, conn)
+			a.sockets.mu.Unlock()
+		}()
+
+		// The connection is hijacked, so the error can't be responded and
+		// is marked for the middleware to only log it.
+		if err := handler(ctx, conn); err != nil && !isConnError(err) {
+			return fmt.Errorf("websocket: %w: %w", http.ErrHijacked, err)
+		}
+
+		return nil
+	}
+
+	return h
+}
+
+// isConnError reports whether the error was produced by the connection
+// itself going away. These errors are part of the normal life of a
+// websocket and must not be treated as handler failures.
+func isConnError(err error) bool {
+	var closeErr *websocket.CloseError
+
+	switch {
+	case errors.As(err, &closeErr),
+		errors.Is(err, websocket.ErrCloseSent),
+		errors.Is(err, net.ErrClosed),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, context.Canceled):
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zucchini/services-golang/foundation/web"
+)
+
+func TestShutdownWebSockets(t *testing.T) {
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)})
+
+	connected := make(chan struct{})
+	returned := make(chan error, 1)
+
+	app.HandleWebSocket("GET /ws", func(ctx context.Context, conn *websocket.Conn) error {
+		close(connected)
+		<-ctx.Done()
+		returned <- ctx.Err()
+		return nil
+	})
+
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Should be able to connect: %s", err)
+	}
+	defer conn.Close()
+
+	<-connected
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.ShutdownWebSockets(ctx); err != nil {
+		t.Fatalf("Should drain the connections: %s", err)
+	}
+
+	if err := <-returned; !errors.Is(err, context.Canceled) {
+		t.Errorf("Should cancel the context of the handler, got %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Should receive a going away close message, got %v", err)
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Should reject new connections while draining, got %v", err)
+	}
+}
+
+func TestGroupWebSocket(t *testing.T) {
+	var calls []string
+	errCh := make(chan error, 1)
+
+	record := func(name string) web.MidHandler {
+		return func(next web.Handler) web.Handler {
+			return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				calls = append(calls, name)
+				err := next(ctx, w, r)
+				if name == "route" {
+					errCh <- err
+				}
+				return err
+			}
+		}
+	}
+
+	app := web.NewApp(web.Config{Shutdown: make(chan os.Signal, 1)})
+
+	failure := errors.New("handler failed")
+	v1 := app.Group("/v1", record("v1"))
+	v1.HandleWebSocket("GET /ws", func(ctx context.Context, conn *websocket.Conn) error {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+			return err
+		}
+		return failure
+	}, record("route"))
+
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/v1/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Should be able to connect: %s", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "hello" {
+		t.Fatalf("Should receive the message, got %q: %v", msg, err)
+	}
+
+	err = <-errCh
+	if !errors.Is(err, http.ErrHijacked) || !errors.Is(err, failure) {
+		t.Errorf("Should wrap the handler error with http.ErrHijacked, got %v", err)
+	}
+
+	if exp := []string{"v1", "route"}; !slices.Equal(calls, exp) {
+		t.Errorf("Should execute the group middleware before the upgrade:\ngot: %v\nexp: %v", calls, exp)
+	}
+}